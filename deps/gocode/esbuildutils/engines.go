@@ -0,0 +1,84 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package esbuildutils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+var engineNames = map[string]api.EngineName{
+	"chrome":  api.EngineChrome,
+	"deno":    api.EngineDeno,
+	"edge":    api.EngineEdge,
+	"firefox": api.EngineFirefox,
+	"hermes":  api.EngineHermes,
+	"ie":      api.EngineIE,
+	"ios":     api.EngineIOS,
+	"node":    api.EngineNode,
+	"opera":   api.EngineOpera,
+	"rhino":   api.EngineRhino,
+	"safari":  api.EngineSafari,
+}
+
+// DefaultEngines are the browsers the UI is built for when no -target is
+// given.
+func DefaultEngines() []api.Engine {
+	return []api.Engine{
+		{Name: api.EngineChrome, Version: "67"},
+		{Name: api.EngineFirefox, Version: "67"},
+		{Name: api.EngineSafari, Version: "11.1"},
+		{Name: api.EngineEdge, Version: "80"},
+	}
+}
+
+// ValidEngineNames returns the engine identifiers accepted by ParseEngines.
+func ValidEngineNames() []string {
+	names := make([]string, 0, len(engineNames))
+	for name := range engineNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseEngines parses a comma separated list of engines with versions, like
+// "chrome93,firefox92,safari14". An empty string yields DefaultEngines().
+func ParseEngines(targets string) ([]api.Engine, error) {
+	if targets == "" {
+		return DefaultEngines(), nil
+	}
+
+	var engines []api.Engine
+	for _, target := range strings.Split(targets, ",") {
+		target = strings.TrimSpace(target)
+		split := strings.IndexAny(target, "0123456789")
+		if split <= 0 {
+			return nil, fmt.Errorf("invalid target '%s', expected engine "+
+				"name followed by version (e.g. chrome93)", target)
+		}
+
+		name, ok := engineNames[target[:split]]
+		if !ok {
+			return nil, fmt.Errorf("unknown engine '%s' in target '%s', "+
+				"valid engines are: %s", target[:split], target,
+				strings.Join(ValidEngineNames(), ", "))
+		}
+
+		engines = append(engines, api.Engine{
+			Name:    name,
+			Version: target[split:],
+		})
+	}
+
+	return engines, nil
+}
@@ -0,0 +1,66 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package esbuildutils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+var loaderNames = map[string]api.Loader{
+	"base64":     api.LoaderBase64,
+	"binary":     api.LoaderBinary,
+	"copy":       api.LoaderCopy,
+	"css":        api.LoaderCSS,
+	"dataurl":    api.LoaderDataURL,
+	"default":    api.LoaderDefault,
+	"empty":      api.LoaderEmpty,
+	"file":       api.LoaderFile,
+	"global-css": api.LoaderGlobalCSS,
+	"js":         api.LoaderJS,
+	"json":       api.LoaderJSON,
+	"jsx":        api.LoaderJSX,
+	"local-css":  api.LoaderLocalCSS,
+	"text":       api.LoaderText,
+	"ts":         api.LoaderTS,
+	"tsx":        api.LoaderTSX,
+}
+
+// ParseLoader converts an esbuild loader name (e.g. "text") to api.Loader.
+func ParseLoader(name string) (api.Loader, error) {
+	loader, ok := loaderNames[name]
+	if !ok {
+		names := make([]string, 0, len(loaderNames))
+		for name := range loaderNames {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return api.LoaderNone, fmt.Errorf("unknown loader '%s', valid "+
+			"loaders are: %s", name, strings.Join(names, ", "))
+	}
+	return loader, nil
+}
+
+// ParseLoaders converts a map of file extensions to loader names into the
+// form expected by api.BuildOptions.
+func ParseLoaders(names map[string]string) (map[string]api.Loader, error) {
+	loaders := make(map[string]api.Loader, len(names))
+	for ext, name := range names {
+		loader, err := ParseLoader(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid loader for '%s': %s",
+				ext, err.Error())
+		}
+		loaders[ext] = loader
+	}
+	return loaders, nil
+}
@@ -0,0 +1,94 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package esbuildutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// useColor reports whether messages written to w are colored with color.
+func useColor(w io.Writer, color api.StderrColor) bool {
+	switch color {
+	case api.ColorAlways:
+		return true
+	case api.ColorIfTerminal:
+		f, ok := w.(*os.File)
+		if !ok {
+			return false
+		}
+		info, err := f.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0
+	}
+	return false
+}
+
+// PrintMessages writes the errors and warnings of result to w, formatted
+// the way esbuild logs them: each with its file, line, column and text,
+// and the source line it points at.
+func PrintMessages(w io.Writer, result api.BuildResult,
+	color api.StderrColor) {
+	for _, kind := range []struct {
+		kind     api.MessageKind
+		messages []api.Message
+	}{
+		{api.ErrorMessage, result.Errors},
+		{api.WarningMessage, result.Warnings},
+	} {
+		formatted := api.FormatMessages(kind.messages,
+			api.FormatMessagesOptions{
+				Kind:  kind.kind,
+				Color: useColor(w, color),
+			})
+		for _, text := range formatted {
+			fmt.Fprint(w, text)
+		}
+	}
+}
+
+type jsonMessage struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Text   string `json:"text"`
+	Level  string `json:"level"`
+}
+
+func appendJSONMessages(messages []jsonMessage, level string,
+	msgs []api.Message) []jsonMessage {
+	for _, msg := range msgs {
+		m := jsonMessage{Text: msg.Text, Level: level}
+		if msg.Location != nil {
+			m.File = msg.Location.File
+			m.Line = msg.Location.Line
+			m.Column = msg.Location.Column
+		}
+		messages = append(messages, m)
+	}
+	return messages
+}
+
+// PrintMessagesJSON writes the errors and warnings of result to w as a
+// single JSON array.
+func PrintMessagesJSON(w io.Writer, result api.BuildResult) error {
+	messages := []jsonMessage{}
+	messages = appendJSONMessages(messages, "error", result.Errors)
+	messages = appendJSONMessages(messages, "warning", result.Warnings)
+
+	data, err := json.Marshal(messages)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
@@ -0,0 +1,151 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package esbuildutils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+func unknownValueError(what, value string, valid []string) error {
+	return fmt.Errorf("unknown %s '%s', valid values are: %s",
+		what, value, strings.Join(valid, ", "))
+}
+
+// ParseFormat converts "esm", "cjs" or "iife" to api.Format.
+func ParseFormat(format string) (api.Format, error) {
+	switch format {
+	case "esm":
+		return api.FormatESModule, nil
+	case "cjs":
+		return api.FormatCommonJS, nil
+	case "iife":
+		return api.FormatIIFE, nil
+	}
+	return api.FormatDefault, unknownValueError("format", format,
+		[]string{"esm", "cjs", "iife"})
+}
+
+// ParseSourceMap converts "linked", "inline", "external", "both" or "none"
+// to api.SourceMap.
+func ParseSourceMap(sourcemap string) (api.SourceMap, error) {
+	switch sourcemap {
+	case "linked":
+		return api.SourceMapLinked, nil
+	case "inline":
+		return api.SourceMapInline, nil
+	case "external":
+		return api.SourceMapExternal, nil
+	case "both":
+		return api.SourceMapInlineAndExternal, nil
+	case "none":
+		return api.SourceMapNone, nil
+	}
+	return api.SourceMapNone, unknownValueError("source map mode", sourcemap,
+		[]string{"linked", "inline", "external", "both", "none"})
+}
+
+// ParseCharset converts "ascii" or "utf8" to api.Charset.
+func ParseCharset(charset string) (api.Charset, error) {
+	switch charset {
+	case "ascii":
+		return api.CharsetASCII, nil
+	case "utf8":
+		return api.CharsetUTF8, nil
+	}
+	return api.CharsetDefault, unknownValueError("charset", charset,
+		[]string{"ascii", "utf8"})
+}
+
+// ParseColor converts "auto", "always" or "never" to api.StderrColor.
+func ParseColor(color string) (api.StderrColor, error) {
+	switch color {
+	case "auto":
+		return api.ColorIfTerminal, nil
+	case "always":
+		return api.ColorAlways, nil
+	case "never":
+		return api.ColorNever, nil
+	}
+	return api.ColorIfTerminal, unknownValueError("color mode", color,
+		[]string{"auto", "always", "never"})
+}
+
+// ParsePlatform converts "browser", "node" or "neutral" to api.Platform.
+func ParsePlatform(platform string) (api.Platform, error) {
+	switch platform {
+	case "browser":
+		return api.PlatformBrowser, nil
+	case "node":
+		return api.PlatformNode, nil
+	case "neutral":
+		return api.PlatformNeutral, nil
+	}
+	return api.PlatformBrowser, unknownValueError("platform", platform,
+		[]string{"browser", "node", "neutral"})
+}
+
+// ParseJSX converts "transform", "preserve" or "automatic" to api.JSX. An
+// empty string keeps esbuild's default.
+func ParseJSX(mode string) (api.JSX, error) {
+	switch mode {
+	case "", "transform":
+		return api.JSXTransform, nil
+	case "preserve":
+		return api.JSXPreserve, nil
+	case "automatic":
+		return api.JSXAutomatic, nil
+	}
+	return api.JSXTransform, unknownValueError("jsx mode", mode,
+		[]string{"transform", "preserve", "automatic"})
+}
+
+// ParseLegalComments converts "none", "inline", "eof", "linked" or
+// "external" to api.LegalComments. An empty string keeps esbuild's default.
+func ParseLegalComments(mode string) (api.LegalComments, error) {
+	switch mode {
+	case "":
+		return api.LegalCommentsDefault, nil
+	case "none":
+		return api.LegalCommentsNone, nil
+	case "inline":
+		return api.LegalCommentsInline, nil
+	case "eof":
+		return api.LegalCommentsEndOfFile, nil
+	case "linked":
+		return api.LegalCommentsLinked, nil
+	case "external":
+		return api.LegalCommentsExternal, nil
+	}
+	return api.LegalCommentsDefault, unknownValueError("legal comments mode",
+		mode, []string{"none", "inline", "eof", "linked", "external"})
+}
+
+// ParseDrop parses a comma separated list of "console" and "debugger" into
+// api.Drop flags. An empty string drops nothing.
+func ParseDrop(names string) (api.Drop, error) {
+	var drop api.Drop
+	if names == "" {
+		return drop, nil
+	}
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "console":
+			drop |= api.DropConsole
+		case "debugger":
+			drop |= api.DropDebugger
+		default:
+			return drop, unknownValueError("drop target", name,
+				[]string{"console", "debugger"})
+		}
+	}
+	return drop, nil
+}
@@ -0,0 +1,57 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package esbuildutils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CheckInputDir verifies that path exists and is a directory.
+func CheckInputDir(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("cannot access source dir %s: %s",
+			path, err.Error())
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("source dir %s is not a directory", path)
+	}
+	return nil
+}
+
+// PrepareOutputDir creates the output dir at path if it doesn't exist yet.
+// Its parent dir must exist, so that a mistyped path doesn't end up
+// creating a whole new tree.
+func PrepareOutputDir(path string) error {
+	info, err := os.Stat(path)
+	if err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("output dir %s is not a directory", path)
+		}
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("cannot access output dir %s: %s",
+			path, err.Error())
+	}
+
+	parent := filepath.Dir(filepath.Clean(path))
+	if info, err := os.Stat(parent); err != nil || !info.IsDir() {
+		return fmt.Errorf("parent dir %s of output dir %s must be an "+
+			"existing directory", parent, path)
+	}
+
+	if err := os.Mkdir(path, 0755); err != nil {
+		return fmt.Errorf("cannot create output dir %s: %s",
+			path, err.Error())
+	}
+	return nil
+}
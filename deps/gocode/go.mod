@@ -3,9 +3,10 @@ module github.com/couchbase/ns_server/deps/gocode
 go 1.21
 
 require (
+	github.com/andybalholm/brotli v1.1.1
 	github.com/aws/aws-sdk-go-v2/config v1.27.18
 	github.com/aws/aws-sdk-go-v2/feature/ec2/imds v1.16.5
-	github.com/evanw/esbuild v0.13.13
+	github.com/evanw/esbuild v0.28.2
 	gocloud.dev v0.37.0
 	golang.org/x/crypto v0.24.0
 )
@@ -0,0 +1,124 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+const (
+	archiveTarGz = "tar.gz"
+	archiveZip   = "zip"
+)
+
+// getArchiveFormat infers the archive format from the extension of path.
+func getArchiveFormat(path string) (string, error) {
+	switch {
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		return archiveTarGz, nil
+	case strings.HasSuffix(path, ".zip"):
+		return archiveZip, nil
+	}
+	return "", fmt.Errorf("cannot infer the archive format of %s, use a "+
+		".tar.gz, .tgz or .zip extension", path)
+}
+
+func writeTarGz(w io.Writer, names []string, files []api.OutputFile) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for i, file := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Name:    names[i],
+			Mode:    0644,
+			Size:    int64(len(file.Contents)),
+			ModTime: now,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := tw.Write(file.Contents); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeZip(w io.Writer, names []string, files []api.OutputFile) error {
+	zw := zip.NewWriter(w)
+	now := time.Now()
+	for i, file := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     names[i],
+			Method:   zip.Deflate,
+			Modified: now,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(file.Contents); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// writeArchive writes files to the archive at path, with their paths
+// relative to outDir.
+func writeArchive(path, outDir string, files []api.OutputFile) error {
+	format, err := getArchiveFormat(path)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(files))
+	for i, file := range files {
+		rel, err := filepath.Rel(outDir, file.Path)
+		if err != nil {
+			return err
+		}
+		names[i] = filepath.ToSlash(rel)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if format == archiveZip {
+		err = writeZip(f, names, files)
+	} else {
+		err = writeTarGz(f, names, files)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// writeBuildArchive writes the outputs of a successful build to the
+// -archive of cfg, if any.
+func writeBuildArchive(cfg Options, files []api.OutputFile) error {
+	if cfg.Archive == "" {
+		return nil
+	}
+	return writeArchive(cfg.Archive, cfg.OutDir, files)
+}
@@ -0,0 +1,126 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readTarGz returns the contents of the files in the .tar.gz at path, by
+// name.
+func readTarGz(t *testing.T, path string) map[string]string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[header.Name] = string(data)
+	}
+}
+
+// readZip returns the contents of the files in the .zip at path, by name.
+func readZip(t *testing.T, path string) map[string]string {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	entries := make(map[string]string)
+	for _, file := range zr.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[file.Name] = string(data)
+	}
+	return entries
+}
+
+func TestArchive(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		read func(*testing.T, string) map[string]string
+	}{
+		{"out.tar.gz", readTarGz},
+		{"out.tgz", readTarGz},
+		{"out.zip", readZip},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := newTestTree(t, map[string]string{
+				"ui/app/main.js": `console.info("main");`,
+			})
+			cfg.Sourcemap = "linked"
+			cfg.Archive = filepath.Join(cfg.InDir, test.name)
+
+			result := mustBuild(t, cfg)
+
+			entries := test.read(t, cfg.Archive)
+			if len(entries) != 2 {
+				t.Errorf("expected main.js and main.js.map, got %d "+
+					"entries", len(entries))
+			}
+			for _, name := range []string{"main.js", "main.js.map"} {
+				if entries[name] != output(t, result, cfg.OutDir, name) {
+					t.Errorf("%s in the archive differs from the output:"+
+						"\n%s", name, entries[name])
+				}
+			}
+			if _, err := os.Stat(cfg.OutDir); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("the out dir is written to: %v", err)
+			}
+		})
+	}
+}
+
+func TestArchiveWatch(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("main");`,
+	})
+	cfg.Archive = filepath.Join(cfg.InDir, "out.tar.gz")
+
+	err := Watch(cfg, WatchOptions{Stop: make(chan struct{})},
+		func(Result) {})
+
+	var optsErr *OptionsError
+	if !errors.As(err, &optsErr) {
+		t.Errorf("expected an *OptionsError, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// parseSize parses a size in bytes, optionally followed by a k or m suffix
+// for KiB or MiB, e.g. 512k.
+func parseSize(size string) (int64, error) {
+	multiplier := int64(1)
+	number := strings.ToLower(size)
+	if n, ok := strings.CutSuffix(number, "k"); ok {
+		multiplier, number = 1<<10, n
+	} else if n, ok := strings.CutSuffix(number, "m"); ok {
+		multiplier, number = 1<<20, n
+	}
+
+	value, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size '%s', expected a number of "+
+			"bytes optionally followed by k or m", size)
+	}
+	return value * multiplier, nil
+}
+
+// jsOutputSize returns the total size of the .js files emitted by a build.
+func jsOutputSize(files []api.OutputFile) int64 {
+	var total int64
+	for _, file := range files {
+		if isJSOutput(file.Path) {
+			total += int64(len(file.Contents))
+		}
+	}
+	return total
+}
+
+// checkSizeBudget reports whether the .js files fit in maxSize
+// bytes, logging the actual and allowed sizes when it doesn't.
+func checkSizeBudget(files []api.OutputFile, maxSize int64) bool {
+	total := jsOutputSize(files)
+	if total <= maxSize {
+		return true
+	}
+	log.Printf("Error: js output is %d bytes, which exceeds the budget of "+
+		"%d bytes\n", total, maxSize)
+	return false
+}
+
+// fitsSizeBudget checks the outputs of a successful build against the
+// -max-size of cfg, if any.
+func fitsSizeBudget(cfg Options, files []api.OutputFile) bool {
+	if cfg.MaxSize == "" {
+		return true
+	}
+	// validated along with the rest of the build options
+	maxSize, _ := parseSize(cfg.MaxSize)
+	return checkSizeBudget(files, maxSize)
+}
+
+// overSizeBudget is the silent counterpart of fitsSizeBudget, for the
+// plugins that shouldn't act on a build the budget check will fail.
+func overSizeBudget(cfg Options, files []api.OutputFile) bool {
+	if cfg.MaxSize == "" {
+		return false
+	}
+	maxSize, _ := parseSize(cfg.MaxSize)
+	return jsOutputSize(files) > maxSize
+}
@@ -0,0 +1,81 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	for size, expected := range map[string]int64{
+		"0":    0,
+		"100":  100,
+		"512k": 512 << 10,
+		"2M":   2 << 20,
+	} {
+		if actual, err := parseSize(size); err != nil || actual != expected {
+			t.Errorf("parseSize(%q) = %d, %v, expected %d",
+				size, actual, err, expected)
+		}
+	}
+	for _, size := range []string{"", "k", "-1", "1g", "1.5k"} {
+		if _, err := parseSize(size); err == nil {
+			t.Errorf("parseSize(%q) accepted an invalid size", size)
+		}
+	}
+}
+
+func TestMaxSize(t *testing.T) {
+	for _, test := range []struct {
+		maxSize    string
+		overBudget bool
+	}{
+		{"1k", false},
+		{"10", true},
+	} {
+		t.Run(test.maxSize, func(t *testing.T) {
+			cfg := newTestTree(t, map[string]string{
+				"ui/app/main.js": `console.info("within a kilobyte");`,
+			})
+			cfg.MaxSize = test.maxSize
+
+			result := mustBuild(t, cfg)
+
+			if result.OverBudget != test.overBudget {
+				t.Errorf("expected OverBudget to be %v", test.overBudget)
+			}
+		})
+	}
+}
+
+func TestMaxSizeWatch(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("small");`,
+	})
+	cfg.MaxSize = "100"
+
+	results := watchResults(t, cfg, WatchOptions{})
+
+	if nextResult(t, results).OverBudget {
+		t.Fatal("the first build is reported over budget")
+	}
+
+	large := `console.info("` + strings.Repeat("x", 200) + `");`
+	err := os.WriteFile(filepath.Join(cfg.InDir, "ui", "app", "main.js"),
+		[]byte(large), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !nextResult(t, results).OverBudget {
+		t.Error("the rebuild is not reported over budget")
+	}
+}
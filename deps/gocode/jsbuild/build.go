@@ -0,0 +1,885 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2016-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/couchbase/ns_server/deps/gocode/esbuildutils"
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+const (
+	ResolveModeImportMap = "importmap"
+	ResolveModeNodePaths = "nodepaths"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Version is the version of the tool, part of the key of cached builds.
+var Version = "dev"
+
+// PureNone given in PureFunctions drops the default pure functions.
+const PureNone = "none"
+
+var defaultPureFunctions = []string{"console.log"}
+
+var (
+	errMissingInDir     = errors.New("path to js source dir must be specified")
+	errMissingOutDir    = errors.New("path to js output dir must be specified")
+	errMissingImportMap = errors.New("path to importmap.json must be " +
+		"specified")
+)
+
+// OptionsError is returned by Run and Watch when the options can't be
+// built.
+type OptionsError struct {
+	Err error
+}
+
+func (e *OptionsError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *OptionsError) Unwrap() error {
+	return e.Err
+}
+
+// Result is the outcome of a build. The build failed if there are Errors.
+// OutputFiles lists the files written, or that would have been written with
+// DryRun or Archive.
+type Result struct {
+	Errors      []api.Message
+	Warnings    []api.Message
+	OutputFiles []api.OutputFile
+	// OverBudget is set when the js output exceeds MaxSize.
+	OverBudget bool
+}
+
+// isJSOutput tells whether path is a js output, with any of the extensions
+// -out-extension can give those.
+func isJSOutput(path string) bool {
+	switch filepath.Ext(path) {
+	case ".js", ".mjs", ".cjs":
+		return true
+	}
+	return false
+}
+
+// getOutExtensions checks the -out-extension overrides, esbuild only
+// supports them for the .js and .css outputs.
+func getOutExtensions(exts map[string]string) (map[string]string, error) {
+	for from, to := range exts {
+		if from != ".js" && from != ".css" {
+			return nil, fmt.Errorf("out extension '%s' must be .js or "+
+				".css", from)
+		}
+		if !strings.HasPrefix(to, ".") {
+			return nil, fmt.Errorf("out extension '%s' for %s must start "+
+				"with '.'", to, from)
+		}
+	}
+	return exts, nil
+}
+
+func newResult(result api.BuildResult) Result {
+	return Result{
+		Errors:      result.Errors,
+		Warnings:    result.Warnings,
+		OutputFiles: result.OutputFiles,
+	}
+}
+
+// addError adds an error found after the build to result. esbuild only
+// logs the messages of the build itself with -verbose, in which case the
+// error is logged here the same way; otherwise the caller prints them all.
+func addError(cfg Options, result *Result, text string) {
+	msg := api.Message{Text: text}
+	result.Errors = append(result.Errors, msg)
+	if cfg.LogFormat == LogFormatText && cfg.Verbose {
+		color, _ := esbuildutils.ParseColor(cfg.Color)
+		esbuildutils.PrintMessages(os.Stderr,
+			api.BuildResult{Errors: []api.Message{msg}}, color)
+	}
+}
+
+// logWarning logs a warning of the tool itself, unless quiet, which
+// silences esbuild's own warnings too.
+func logWarning(quiet bool, format string, args ...any) {
+	if !quiet {
+		log.Printf(format, args...)
+	}
+}
+
+// readTextArg returns the value of a flag that takes either literal text or
+// a @path reference to a file with the text.
+func readTextArg(value string) (string, error) {
+	path, isFile := strings.CutPrefix(value, "@")
+	if !isFile {
+		return value, nil
+	}
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s: %s", path, err.Error())
+	}
+	return string(text), nil
+}
+
+// getPureFunctions returns the default pure functions followed by names,
+// with the defaults left out if names contains PureNone. With keepConsole
+// console.log is never considered pure, so its calls always stay.
+func getPureFunctions(names []string, keepConsole bool) []string {
+	var pure []string
+	if !slices.Contains(names, PureNone) {
+		pure = append(pure, defaultPureFunctions...)
+	}
+	pure = append(pure, names...)
+	return slices.DeleteFunc(pure, func(name string) bool {
+		return name == PureNone || (keepConsole && name == "console.log")
+	})
+}
+
+// getDefines returns the -define replacements along with process.env.NAME
+// ones for the -define-env variables. A variable that is not set is
+// replaced with undefined, unless -define-env-strict is on. Explicit
+// -define entries take precedence.
+func getDefines(cfg Options) (map[string]string, error) {
+	if cfg.DefineEnv == "" {
+		return cfg.Defines, nil
+	}
+
+	defines := make(map[string]string)
+	for _, name := range strings.Split(cfg.DefineEnv, ",") {
+		name = strings.TrimSpace(name)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			if cfg.DefineEnvStrict {
+				return nil, fmt.Errorf("environment variable %s given "+
+					"to -define-env is not set", name)
+			}
+			defines["process.env."+name] = "undefined"
+			continue
+		}
+		quoted, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		defines["process.env."+name] = string(quoted)
+	}
+	for key, value := range cfg.Defines {
+		defines[key] = value
+	}
+	return defines, nil
+}
+
+// inDirPaths joins the relative paths in paths with inDir.
+func inDirPaths(inDir string, paths []string) []string {
+	var joined []string
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(inDir, path)
+		}
+		joined = append(joined, path)
+	}
+	return joined
+}
+
+// splitList splits a comma separated list, an empty list has no items.
+func splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		items = append(items, strings.TrimSpace(item))
+	}
+	return items
+}
+
+// inDirPath joins path with inDir if it is relative and not empty.
+func inDirPath(inDir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(inDir, path)
+}
+
+// expandEntryPoints converts entries, relative to inDir unless absolute, to
+// esbuild entry points, replacing the glob patterns with the files they
+// match. A pattern that matches nothing is an error. An entry given as
+// name=path is output as name, relative to the output dir and without the
+// extension, rather than under the name of the file.
+func expandEntryPoints(inDir string,
+	entries []string) ([]api.EntryPoint, error) {
+	var expanded []api.EntryPoint
+	for _, entry := range entries {
+		name, path, named := strings.Cut(entry, "=")
+		if !named {
+			name, path = "", entry
+		}
+		path = inDirPath(inDir, path)
+
+		if !strings.ContainsAny(path, "*?[") {
+			expanded = append(expanded, api.EntryPoint{
+				InputPath:  path,
+				OutputPath: name,
+			})
+			continue
+		}
+		if named {
+			return nil, fmt.Errorf("named entry point '%s' can't be a "+
+				"pattern", entry)
+		}
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entry point pattern '%s': %s",
+				entry, err.Error())
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("entry point pattern '%s' matches no "+
+				"files", entry)
+		}
+		for _, match := range matches {
+			expanded = append(expanded, api.EntryPoint{InputPath: match})
+		}
+	}
+	return expanded, nil
+}
+
+// makePathsAbsolute makes the dirs in cfg that esbuild resolves paths
+// against absolute, so that the build doesn't depend on the current dir
+// beyond the paths given to it.
+func makePathsAbsolute(cfg *Options) error {
+	dirs := []*string{&cfg.InDir, &cfg.OutDir, &cfg.StdinResolveDir,
+		&cfg.MapDir}
+	for i := range cfg.NodePaths {
+		dirs = append(dirs, &cfg.NodePaths[i])
+	}
+
+	for _, dir := range dirs {
+		if *dir == "" {
+			continue
+		}
+		abs, err := filepath.Abs(*dir)
+		if err != nil {
+			return err
+		}
+		*dir = abs
+	}
+	return nil
+}
+
+// buildOptions checks cfg and converts it to the esbuild options of the
+// build.
+func buildOptions(cfg Options) (api.BuildOptions, error) {
+	if cfg.InDir == "" && !cfg.Stdin {
+		return api.BuildOptions{}, errMissingInDir
+	}
+
+	if cfg.OutDir == "" && !cfg.Stdin && !cfg.TransformOnly {
+		return api.BuildOptions{}, errMissingOutDir
+	}
+
+	if cfg.InDir != "" {
+		if err := esbuildutils.CheckInputDir(cfg.InDir); err != nil {
+			return api.BuildOptions{}, err
+		}
+	}
+
+	engines, err := esbuildutils.ParseEngines(cfg.Target)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+
+	loaders, err := esbuildutils.ParseLoaders(cfg.Loaders)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+
+	fileLoaderNames, err := esbuildutils.ParseLoaders(cfg.FileLoaders)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+	fileLoaders, err := newFileLoaders(fileLoaderNames)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+
+	format, err := esbuildutils.ParseFormat(cfg.Format)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+
+	sourcemap, err := esbuildutils.ParseSourceMap(cfg.Sourcemap)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+	if cfg.NoSourcemapComment {
+		switch sourcemap {
+		case api.SourceMapLinked:
+			sourcemap = api.SourceMapExternal
+		case api.SourceMapInline, api.SourceMapInlineAndExternal:
+			return api.BuildOptions{}, errors.New("-no-sourcemap-comment " +
+				"can't be used with inline source maps")
+		}
+	}
+	if cfg.SourcesRoot != "" && sourcemap == api.SourceMapNone {
+		return api.BuildOptions{}, errors.New("-sources-root needs " +
+			"source maps")
+	}
+
+	legalComments, err := esbuildutils.ParseLegalComments(cfg.LegalComments)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+
+	color, err := esbuildutils.ParseColor(cfg.Color)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+
+	platform, err := esbuildutils.ParsePlatform(cfg.Platform)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+
+	jsx, err := esbuildutils.ParseJSX(cfg.JSX)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+
+	charset, err := esbuildutils.ParseCharset(cfg.Charset)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+
+	drop, err := esbuildutils.ParseDrop(cfg.Drop)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+
+	if cfg.KeepConsole && drop&api.DropConsole != 0 {
+		return api.BuildOptions{}, errors.New("-keep-console can't be " +
+			"used with -drop=console")
+	}
+
+	defines, err := getDefines(cfg)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+
+	pure := getPureFunctions(cfg.PureFunctions, cfg.KeepConsole)
+
+	banner, err := readTextArg(cfg.Banner)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+
+	footer, err := readTextArg(cfg.Footer)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+
+	splitting := cfg.Splitting
+	if splitting && format != api.FormatESModule {
+		logWarning(cfg.Quiet, "Warning: code splitting is disabled for %s "+
+			"format\n", cfg.Format)
+		splitting = false
+	}
+
+	chunkNames := cfg.ChunkNames
+	if cfg.ChunkDir != "" {
+		if chunkNames == "" {
+			chunkNames = "[name]-[hash]"
+		}
+		chunkNames = path.Join(cfg.ChunkDir, chunkNames)
+	}
+
+	minifyWhitespace := cfg.Minify
+	if cfg.MinifyWhitespace != nil {
+		minifyWhitespace = *cfg.MinifyWhitespace
+	}
+	minifySyntax := cfg.Minify
+	if cfg.MinifySyntax != nil {
+		minifySyntax = *cfg.MinifySyntax
+	}
+	// KeepNames has to re-attach the original names to mangled functions
+	// and classes, so identifier minification only pays off when names are
+	// not kept
+	minifyIdentifiers := cfg.Minify && !cfg.KeepNames
+	if cfg.MinifyIdentifiers != nil {
+		minifyIdentifiers = *cfg.MinifyIdentifiers
+	}
+
+	var plugins []api.Plugin
+	// the files the plugins write besides the outputs, for -cache-dir
+	var written *writtenFiles
+	if cfg.CacheDir != "" {
+		written = newWrittenFiles()
+	}
+
+	// esbuild marks the externals and replaces the aliases itself, before
+	// resolving anything
+	passThrough := slices.Clone(cfg.Externals)
+	for from := range cfg.Aliases {
+		passThrough = append(passThrough, from)
+	}
+
+	if cfg.WarningsAsErrors {
+		plugins = append(plugins, getWarningsAsErrorsPlugin())
+	}
+
+	if cfg.CyclesFatal && !cfg.DetectCycles {
+		return api.BuildOptions{}, errors.New("-cycles-fatal needs " +
+			"-detect-cycles")
+	}
+	if cfg.DetectCycles {
+		plugins = append(plugins,
+			getCyclesPlugin(cfg.InDir, cfg.CyclesFatal))
+	}
+
+	if cfg.DuplicatesFatal && !cfg.DetectDuplicates {
+		return api.BuildOptions{}, errors.New("-duplicates-fatal needs " +
+			"-detect-duplicates")
+	}
+	if cfg.DetectDuplicates {
+		plugins = append(plugins,
+			getDuplicatesPlugin(cfg.InDir, cfg.DuplicatesFatal))
+	}
+
+	err = checkNoMinifyGlobs(cfg.NoMinifyGlobs, format, cfg.PublicPath)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+	if len(cfg.NoMinifyGlobs) > 0 {
+		// ahead of the import map, to see what it resolves to
+		plugins = append(plugins, getNoMinifyPlugin(cfg.OutDir,
+			cfg.PublicPath, cfg.NoMinifyGlobs, written))
+	}
+
+	switch cfg.ResolveMode {
+	case ResolveModeImportMap:
+		if len(cfg.ImportMapPaths) == 0 {
+			return api.BuildOptions{}, errMissingImportMap
+		}
+		importMapPlugin, err := getImportMapPlugin(cfg.ImportMapPaths,
+			cfg.InDir, passThrough, cfg.StrictImportMap,
+			cfg.Tsconfig != "", cfg.VerifyImportMap, cfg.Quiet)
+		if err != nil {
+			return api.BuildOptions{}, err
+		}
+		plugins = append(plugins, importMapPlugin)
+	case ResolveModeNodePaths:
+		// an import map takes precedence over the node paths, which the
+		// specifiers missing from it fall through to
+		if len(cfg.ImportMapPaths) > 0 {
+			importMapPlugin, err := getImportMapPlugin(cfg.ImportMapPaths,
+				cfg.InDir, passThrough, false, false, cfg.VerifyImportMap,
+				cfg.Quiet)
+			if err != nil {
+				return api.BuildOptions{}, err
+			}
+			plugins = append(plugins, importMapPlugin)
+		} else if cfg.VerifyImportMap {
+			return api.BuildOptions{}, errors.New("-verify-importmap " +
+				"needs -importmap-path")
+		}
+	default:
+		return api.BuildOptions{}, fmt.Errorf("unknown resolve mode '%s'",
+			cfg.ResolveMode)
+	}
+
+	if len(cfg.FeaturesOff) > 0 {
+		if err := checkFeatures(cfg.FeaturesOff); err != nil {
+			return api.BuildOptions{}, err
+		}
+		// ahead of the loaders, which would load the modules otherwise
+		plugins = append(plugins, getFeaturesPlugin(cfg.FeaturesOff))
+	}
+
+	if len(fileLoaders) > 0 {
+		plugins = append(plugins, getFileLoaderPlugin(cfg.InDir, fileLoaders))
+	}
+
+	if cfg.DefaultLoader != "" {
+		defaultLoader, err := esbuildutils.ParseLoader(cfg.DefaultLoader)
+		if err != nil {
+			return api.BuildOptions{}, fmt.Errorf("invalid -loader-default: "+
+				"%s", err.Error())
+		}
+		plugins = append(plugins,
+			getDefaultLoaderPlugin(defaultLoader, loaders))
+	}
+
+	if cfg.Timing {
+		plugins = append(plugins,
+			getTimingPlugin(cfg.InDir, cfg.Metafile != ""))
+	}
+
+	if cfg.Metafile != "" && !cfg.DryRun {
+		plugins = append(plugins, getMetafilePlugin(cfg.Metafile))
+	}
+
+	entryPoints := []api.EntryPoint{
+		{InputPath: inDirPath(cfg.InDir, cfg.EntryRelative)}}
+	if len(cfg.EntryPoints) > 0 {
+		entryPoints, err = expandEntryPoints(cfg.InDir, cfg.EntryPoints)
+		if err != nil {
+			return api.BuildOptions{}, err
+		}
+	}
+
+	var resolveExtensions []string
+	if cfg.ResolveExtensions != "" {
+		for _, ext := range strings.Split(cfg.ResolveExtensions, ",") {
+			if !strings.HasPrefix(ext, ".") {
+				return api.BuildOptions{}, fmt.Errorf("resolve extension "+
+					"'%s' must start with '.'", ext)
+			}
+			resolveExtensions = append(resolveExtensions, ext)
+		}
+	}
+
+	for _, props := range []struct{ flag, pattern string }{
+		{"-mangle-props", cfg.MangleProps},
+		{"-reserve-props", cfg.ReserveProps},
+	} {
+		if _, err := regexp.Compile(props.pattern); err != nil {
+			return api.BuildOptions{}, fmt.Errorf("invalid %s: %s",
+				props.flag, err.Error())
+		}
+	}
+	if cfg.ReserveProps != "" && cfg.MangleProps == "" {
+		return api.BuildOptions{}, errors.New("-reserve-props needs " +
+			"-mangle-props")
+	}
+
+	outExtensions, err := getOutExtensions(cfg.OutExtensions)
+	if err != nil {
+		return api.BuildOptions{}, err
+	}
+
+	if cfg.MaxSize != "" {
+		if _, err := parseSize(cfg.MaxSize); err != nil {
+			return api.BuildOptions{}, err
+		}
+	}
+
+	treeShaking := api.TreeShakingFalse
+	if cfg.TreeShaking {
+		treeShaking = api.TreeShakingTrue
+	}
+
+	opts := api.BuildOptions{
+		MinifyWhitespace:  minifyWhitespace,
+		MinifyIdentifiers: minifyIdentifiers,
+		MinifySyntax:      minifySyntax,
+		Pure:              pure,
+		Drop:              drop,
+		TreeShaking:       treeShaking,
+		IgnoreAnnotations: cfg.IgnoreAnnotations,
+		Plugins:           plugins,
+		NodePaths:         cfg.NodePaths,
+		ResolveExtensions: resolveExtensions,
+		Conditions:        splitList(cfg.Conditions),
+		MainFields:        splitList(cfg.MainFields),
+		Alias:             cfg.Aliases,
+		Sourcemap:         sourcemap,
+		SourceRoot:        cfg.SourcesRoot,
+		LegalComments:     legalComments,
+		Charset:           charset,
+		JSX:               jsx,
+		JSXFactory:        cfg.JSXFactory,
+		JSXFragment:       cfg.JSXFragment,
+		KeepNames:         cfg.KeepNames,
+		MangleProps:       cfg.MangleProps,
+		ReserveProps:      cfg.ReserveProps,
+		Bundle:            true,
+		PreserveSymlinks:  cfg.PreserveSymlinks,
+		Splitting:         splitting,
+		Metafile:          cfg.Metafile != "",
+		Write:             true,
+		Format:            format,
+		Platform:          platform,
+		// LogLevel: api.LogLevelWarning,
+		LogLevel:     api.LogLevelInfo,
+		Color:        color,
+		LogLimit:     cfg.LogLimit,
+		Outdir:       cfg.OutDir,
+		EntryNames:   cfg.EntryNames,
+		ChunkNames:   chunkNames,
+		AssetNames:   cfg.AssetNames,
+		OutExtension: outExtensions,
+		PublicPath:   cfg.PublicPath,
+		Loader:       loaders,
+		Define:       defines,
+		External:     cfg.Externals,
+		Inject:       inDirPaths(cfg.InDir, cfg.Injects),
+		Tsconfig:     inDirPath(cfg.InDir, cfg.Tsconfig),
+		Engines:      engines,
+	}
+
+	// with names for the entry points that have them
+	opts.EntryPointsAdvanced = entryPoints
+
+	// for the import graph checks, which run ahead of the other plugins
+	if cfg.DetectCycles || cfg.DetectDuplicates {
+		opts.Metafile = true
+	}
+
+	opts.AbsWorkingDir = cfg.InDir
+	if opts.AbsWorkingDir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return api.BuildOptions{}, err
+		}
+		opts.AbsWorkingDir = wd
+	}
+
+	if banner != "" {
+		opts.Banner = map[string]string{"js": banner}
+	}
+	if footer != "" {
+		opts.Footer = map[string]string{"js": footer}
+	}
+
+	switch {
+	case cfg.Quiet && cfg.Verbose:
+		return api.BuildOptions{}, errors.New("-quiet and -verbose can't " +
+			"be used together")
+	case cfg.Quiet:
+		opts.LogLevel = api.LogLevelError
+	case cfg.Verbose:
+		opts.LogLevel = api.LogLevelDebug
+	}
+
+	switch cfg.LogFormat {
+	case LogFormatText:
+		// the errors and warnings are printed from the Result, so that
+		// those found after the build are printed the same way; esbuild
+		// only logs on its own with -verbose, messages included
+		if !cfg.Verbose {
+			opts.LogLevel = api.LogLevelSilent
+		}
+	case LogFormatJSON:
+		opts.LogLevel = api.LogLevelSilent
+	default:
+		return api.BuildOptions{}, fmt.Errorf("unknown log format '%s'",
+			cfg.LogFormat)
+	}
+
+	if cfg.Stdin {
+		if slices.Contains(cfg.ImportMapPaths, ImportMapStdin) {
+			return api.BuildOptions{}, errors.New("-stdin can't be used " +
+				"with an import map read from stdin")
+		}
+		if err := setStdinOptions(cfg, &opts); err != nil {
+			return api.BuildOptions{}, err
+		}
+		if !opts.Write && cfg.LogFormat == LogFormatJSON {
+			return api.BuildOptions{}, errors.New("-log-format=" +
+				LogFormatJSON + " needs -out-dir when building stdin, as " +
+				"the bundle would be written to stdout")
+		}
+	}
+
+	if err := checkGlobalName(cfg.GlobalName, opts); err != nil {
+		return api.BuildOptions{}, err
+	}
+	opts.GlobalName = cfg.GlobalName
+
+	if cfg.Archive != "" {
+		if _, err := getArchiveFormat(cfg.Archive); err != nil {
+			return api.BuildOptions{}, err
+		}
+		if cfg.OutDir == "" {
+			return api.BuildOptions{}, errors.New("-archive needs -out-dir, " +
+				"the paths in the archive are relative to it")
+		}
+		if cfg.DryRun {
+			return api.BuildOptions{}, errors.New("-archive can't be used " +
+				"with -dry-run")
+		}
+	}
+
+	if cfg.DryRun || cfg.Archive != "" {
+		opts.Write = false
+	}
+
+	if cfg.MapDir != "" {
+		if !opts.Write {
+			return api.BuildOptions{}, errors.New("-write-map-dir needs " +
+				"the outputs to be written to -out-dir")
+		}
+		switch opts.Sourcemap {
+		case api.SourceMapNone, api.SourceMapInline:
+			return api.BuildOptions{}, errors.New("-write-map-dir needs " +
+				"source maps in .map files")
+		}
+		opts.Plugins = append(opts.Plugins,
+			getMapDirPlugin(cfg.OutDir, cfg.MapDir, written))
+	}
+
+	if err := checkManifestSchema(cfg.ManifestSchema); err != nil {
+		return api.BuildOptions{}, err
+	}
+	if manifestPath := getManifestPath(cfg); manifestPath != "" && opts.Write {
+		opts.Metafile = true
+		opts.Plugins = append(opts.Plugins, getManifestPlugin(cfg.InDir,
+			cfg.OutDir, manifestPath, cfg.ManifestSchema))
+	}
+
+	if cfg.GraphOut != "" && !cfg.DryRun {
+		opts.Metafile = true
+		opts.Plugins = append(opts.Plugins,
+			getGraphPlugin(cfg.InDir, cfg.GraphOut))
+	}
+
+	if cfg.ReportUnused {
+		opts.Metafile = true
+		opts.Plugins = append(opts.Plugins, getUnusedReportPlugin(cfg.InDir))
+	}
+
+	if cfg.Summary {
+		opts.Metafile = true
+		opts.Plugins = append(opts.Plugins, getSummaryPlugin(cfg.OutDir))
+	}
+
+	if cfg.Integrity && opts.Write {
+		opts.Plugins = append(opts.Plugins, getIntegrityPlugin(cfg.OutDir))
+	}
+
+	if cfg.Compress != "" {
+		enabled, err := parseCompressors(cfg.Compress)
+		if err != nil {
+			return api.BuildOptions{}, err
+		}
+		if !opts.Write {
+			return api.BuildOptions{}, errors.New("-compress needs the " +
+				"outputs to be written to -out-dir")
+		}
+		opts.Plugins = append(opts.Plugins, getCompressPlugin(enabled))
+	}
+
+	// after the compress plugin, so the command sees the compressed files
+	if cfg.PostBuild != "" {
+		if !opts.Write {
+			return api.BuildOptions{}, errors.New("-post-build needs the " +
+				"outputs to be written to -out-dir")
+		}
+		opts.Plugins = append(opts.Plugins, getPostBuildPlugin(cfg))
+	}
+
+	if cfg.CacheDir != "" {
+		if !opts.Write {
+			return api.BuildOptions{}, errors.New("-cache-dir needs the " +
+				"outputs to be written to -out-dir")
+		}
+		if len(importMapFiles(cfg.ImportMapPaths)) <
+			len(cfg.ImportMapPaths) {
+			return api.BuildOptions{}, errors.New("-cache-dir can't tell " +
+				"if an import map read from stdin or a URL has changed")
+		}
+		// these report on the build, which doesn't run when its outputs
+		// are restored from the cache
+		for _, report := range []struct {
+			flag string
+			set  bool
+		}{
+			{"-summary", cfg.Summary},
+			{"-timing", cfg.Timing},
+			{"-report-unused", cfg.ReportUnused},
+			{"-detect-cycles", cfg.DetectCycles},
+			{"-detect-duplicates", cfg.DetectDuplicates},
+		} {
+			if report.set {
+				return api.BuildOptions{}, fmt.Errorf("-cache-dir can't "+
+					"be used with %s, which builds restored from the "+
+					"cache would skip", report.flag)
+			}
+		}
+		opts.Metafile = true
+		opts.Plugins = append(opts.Plugins, getCachePlugin(cfg, written))
+	}
+
+	for i, plugin := range opts.Plugins {
+		opts.Plugins[i] = guardPlugin(plugin)
+	}
+
+	return opts, nil
+}
+
+// prepare makes the paths in *cfg absolute and builds the esbuild options
+// for it. The out dir is only created once they are, so that invalid
+// options leave it alone.
+func prepare(cfg *Options) (api.BuildOptions, error) {
+	if err := makePathsAbsolute(cfg); err != nil {
+		return api.BuildOptions{}, &OptionsError{err}
+	}
+	opts, err := buildOptions(*cfg)
+	if err != nil {
+		return api.BuildOptions{}, &OptionsError{err}
+	}
+	if cfg.OutDir != "" && !cfg.DryRun && cfg.Archive == "" {
+		if err := esbuildutils.PrepareOutputDir(cfg.OutDir); err != nil {
+			return api.BuildOptions{}, &OptionsError{err}
+		}
+	}
+	return opts, nil
+}
+
+// Run builds cfg, twice if cfg.DualFormat is set, or only transforms its
+// entry point if cfg.TransformOnly is. Build errors are reported
+// in the result, an error is only returned if cfg is invalid (as an
+// *OptionsError) or the build crashed.
+func Run(cfg Options) (result Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("build crashed: %v (%s)", r, PanicHint)
+		}
+	}()
+
+	if cfg.TransformOnly {
+		return runTransform(cfg)
+	}
+	if cfg.DualFormat {
+		return runDualFormat(cfg)
+	}
+
+	opts, err := prepare(&cfg)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result = cachedBuild(cfg, opts)
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	if err := writeBuildArchive(cfg, result.OutputFiles); err != nil {
+		addError(cfg, &result, fmt.Sprintf("cannot write archive %s: %s",
+			cfg.Archive, err.Error()))
+		return result, nil
+	}
+
+	result.OverBudget = !fitsSizeBudget(cfg, result.OutputFiles)
+	return result, nil
+}
@@ -0,0 +1,1237 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/importmap.json": `{"imports": {"lib": "./web_modules/lib.js"}}`,
+		"ui/web_modules/lib.js": `export function greet(name) {
+			console.info("hello " + name);
+		}`,
+		"ui/app/main.js": `import { greet } from "lib";
+			greet("world");`,
+	})
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, `"hello "`) {
+		t.Errorf("lib is not bundled into main.js:\n%s", main)
+	}
+	written := readFile(t, filepath.Join(cfg.OutDir, "main.js"))
+	if written != main {
+		t.Errorf("main.js on disk differs from the output:\n%s", written)
+	}
+}
+
+func TestRunBuildError(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `let x = ;`,
+	})
+
+	result := mustRun(t, cfg)
+
+	if len(result.Errors) != 1 {
+		t.Fatalf("expected one error, got %v", messageTexts(result.Errors))
+	}
+	loc := result.Errors[0].Location
+	if loc == nil || loc.File != "ui/app/main.js" || loc.Line != 1 {
+		t.Errorf("error has the wrong location: %+v", loc)
+	}
+}
+
+func TestRunInvalidOptions(t *testing.T) {
+	for name, change := range map[string]func(*Options){
+		"no in dir":    func(cfg *Options) { cfg.InDir = "" },
+		"no out dir":   func(cfg *Options) { cfg.OutDir = "" },
+		"bad format":   func(cfg *Options) { cfg.Format = "amd" },
+		"bad resolve":  func(cfg *Options) { cfg.ResolveMode = "magic" },
+		"no importmap": func(cfg *Options) { cfg.ImportMapPaths = nil },
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := newTestTree(t, map[string]string{
+				"ui/app/main.js": `console.info(1);`,
+			})
+			change(&cfg)
+
+			_, err := Run(cfg)
+
+			var optsErr *OptionsError
+			if !errors.As(err, &optsErr) {
+				t.Errorf("expected an *OptionsError, got %v", err)
+			}
+		})
+	}
+}
+
+// watchResults starts watching cfg and returns the channel the results of
+// the builds are sent to. Watching stops at the end of the test.
+func watchResults(t *testing.T, cfg Options,
+	watchOpts WatchOptions) <-chan Result {
+	t.Helper()
+	results := make(chan Result, 16)
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	watchOpts.Stop = stop
+	go func() {
+		done <- Watch(cfg, watchOpts, func(result Result) {
+			results <- result
+		})
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		if err := <-done; err != nil {
+			t.Errorf("Watch: %v", err)
+		}
+	})
+	return results
+}
+
+// nextResult waits for the next result from results.
+func nextResult(t *testing.T, results <-chan Result) Result {
+	t.Helper()
+	select {
+	case result := <-results:
+		return result
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for a build")
+	}
+	return Result{}
+}
+
+func TestWatch(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("first");`,
+	})
+	mainPath := filepath.Join(cfg.InDir, "ui", "app", "main.js")
+	outPath := filepath.Join(cfg.OutDir, "main.js")
+
+	results := watchResults(t, cfg, WatchOptions{})
+
+	if result := nextResult(t, results); len(result.Errors) > 0 {
+		t.Fatalf("build failed: %v", messageTexts(result.Errors))
+	}
+	if out := readFile(t, outPath); !strings.Contains(out, "first") {
+		t.Fatalf("unexpected output of the first build:\n%s", out)
+	}
+
+	err := os.WriteFile(mainPath, []byte(`console.info("second");`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := nextResult(t, results); len(result.Errors) > 0 {
+		t.Fatalf("rebuild failed: %v", messageTexts(result.Errors))
+	}
+	if out := readFile(t, outPath); !strings.Contains(out, "second") {
+		t.Errorf("the change is not rebuilt:\n%s", out)
+	}
+}
+
+func TestWatchInvalidOptions(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info(1);`,
+	})
+	cfg.DryRun = true
+
+	err := Watch(cfg, WatchOptions{Stop: make(chan struct{})},
+		func(Result) {})
+
+	var optsErr *OptionsError
+	if !errors.As(err, &optsErr) {
+		t.Errorf("expected an *OptionsError, got %v", err)
+	}
+}
+
+// jsOutputs returns the base names of the js outputs of a build.
+func jsOutputs(result Result) []string {
+	var names []string
+	for _, file := range result.OutputFiles {
+		if isJSOutput(file.Path) {
+			names = append(names, filepath.Base(file.Path))
+		}
+	}
+	return names
+}
+
+// sharedCodeTree has two entry points that share code.
+var sharedCodeTree = map[string]string{
+	"ui/app/shared.js": `export function shared() {
+		return "the shared code";
+	}`,
+	"ui/app/main.js": `import { shared } from "./shared.js";
+		console.info("main", shared());`,
+	"ui/app/admin.js": `import { shared } from "./shared.js";
+		console.info("admin", shared());`,
+}
+
+func TestEntryPointsSharedChunk(t *testing.T) {
+	cfg := newTestTree(t, sharedCodeTree)
+	cfg.EntryPoints = []string{"ui/app/main.js", "ui/app/admin.js"}
+
+	result := mustBuild(t, cfg)
+
+	var chunks []string
+	for _, name := range jsOutputs(result) {
+		if name != "main.js" && name != "admin.js" {
+			chunks = append(chunks, name)
+		}
+	}
+	if len(chunks) != 1 {
+		t.Fatalf("expected a single shared chunk, got %v", chunks)
+	}
+	chunk := output(t, result, cfg.OutDir, chunks[0])
+	if !strings.Contains(chunk, "the shared code") {
+		t.Errorf("the shared code is not in %s:\n%s", chunks[0], chunk)
+	}
+	for _, name := range []string{"main.js", "admin.js"} {
+		entry := output(t, result, cfg.OutDir, name)
+		if strings.Contains(entry, "the shared code") {
+			t.Errorf("the shared code is duplicated in %s", name)
+		}
+		if !strings.Contains(entry, chunks[0]) {
+			t.Errorf("%s doesn't import %s:\n%s", name, chunks[0], entry)
+		}
+	}
+}
+
+func TestSplitting(t *testing.T) {
+	cfg := newTestTree(t, sharedCodeTree)
+	cfg.EntryPoints = []string{"ui/app/main.js", "ui/app/admin.js"}
+	cfg.ChunkDir = "chunks"
+
+	result := mustBuild(t, cfg)
+
+	var chunks []string
+	for _, file := range result.OutputFiles {
+		if filepath.Dir(file.Path) == filepath.Join(cfg.OutDir, "chunks") {
+			chunks = append(chunks, filepath.Base(file.Path))
+		}
+	}
+	if len(chunks) != 1 || len(result.OutputFiles) != 3 {
+		t.Errorf("expected a single chunk in chunks/, got %v",
+			jsOutputs(result))
+	}
+
+	cfg.Splitting = false
+	result = mustBuild(t, cfg)
+
+	if names := jsOutputs(result); len(names) != 2 {
+		t.Errorf("expected only the entry points, got %v", names)
+	}
+	for _, name := range []string{"main.js", "admin.js"} {
+		entry := output(t, result, cfg.OutDir, name)
+		if !strings.Contains(entry, "the shared code") {
+			t.Errorf("%s is not self-contained:\n%s", name, entry)
+		}
+	}
+}
+
+func TestEntryPointsDefault(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js":  `console.info("main");`,
+		"ui/app/other.js": `console.info("other");`,
+	})
+
+	result := mustBuild(t, cfg)
+
+	if names := jsOutputs(result); len(names) != 1 || names[0] != "main.js" {
+		t.Errorf("expected only main.js to be built, got %v", names)
+	}
+}
+
+func TestEntryRelative(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"src/index.js": `console.info("another layout");`,
+	})
+	cfg.EntryRelative = "src/index.js"
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "index.js")
+	if !strings.Contains(main, "another layout") {
+		t.Errorf("unexpected index.js:\n%s", main)
+	}
+}
+
+func TestNamedEntryPoints(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js":     `console.info("main");`,
+		"ui/login/source.js": `console.info("login");`,
+		"ui/admin/source.js": `console.info("admin");`,
+	})
+	cfg.EntryPoints = []string{"ui/app/main.js", "login=ui/login/source.js",
+		"nested/admin=ui/admin/source.js"}
+
+	result := mustBuild(t, cfg)
+
+	for name, text := range map[string]string{
+		"main.js":         "main",
+		"login.js":        "login",
+		"nested/admin.js": "admin",
+	} {
+		out := output(t, result, cfg.OutDir, name)
+		if !strings.Contains(out, `"`+text+`"`) {
+			t.Errorf("unexpected %s:\n%s", name, out)
+		}
+	}
+}
+
+func TestEntryPointsGlob(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/widgets/clock/index.js":  `console.info("clock");`,
+		"ui/widgets/chart/index.js":  `console.info("chart");`,
+		"ui/widgets/gauge/index.js":  `console.info("gauge");`,
+		"ui/widgets/gauge/helper.js": `console.info("helper");`,
+	})
+	cfg.EntryPoints = []string{"ui/widgets/*/index.js"}
+
+	result := mustBuild(t, cfg)
+
+	if len(result.OutputFiles) != 3 {
+		t.Errorf("expected 3 outputs, got %d", len(result.OutputFiles))
+	}
+	for _, widget := range []string{"clock", "chart", "gauge"} {
+		out := output(t, result, cfg.OutDir, widget+"/index.js")
+		if !strings.Contains(out, `"`+widget+`"`) {
+			t.Errorf("unexpected output of %s:\n%s", widget, out)
+		}
+	}
+
+	cfg.EntryPoints = []string{"ui/widgets/*/main.js"}
+	_, err := Run(cfg)
+	expected := "entry point pattern 'ui/widgets/*/main.js' matches no files"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	for _, test := range []struct {
+		format, source string
+		check          func(string) bool
+	}{
+		{"esm", `export const answer = 42;`, func(out string) bool {
+			return strings.Contains(out, "export{")
+		}},
+		{"cjs", `export const answer = 42;`, func(out string) bool {
+			return strings.Contains(out, "module.exports=")
+		}},
+		{"iife", `console.info(42);`, func(out string) bool {
+			return strings.HasPrefix(out, "(()=>{")
+		}},
+	} {
+		t.Run(test.format, func(t *testing.T) {
+			cfg := newTestTree(t, map[string]string{
+				"ui/app/main.js": test.source,
+			})
+			cfg.Format = test.format
+
+			// splitting is on by default, and turned off for cjs and iife
+			result := mustBuild(t, cfg)
+
+			main := output(t, result, cfg.OutDir, "main.js")
+			if !test.check(main) {
+				t.Errorf("main.js is not %s:\n%s", test.format, main)
+			}
+		})
+	}
+}
+
+func TestOutExtensions(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("main");`,
+	})
+	cfg.OutExtensions = map[string]string{".js": ".mjs"}
+	cfg.EntryNames = "[name]-[hash]"
+	cfg.Sourcemap = "linked"
+
+	result := mustBuild(t, cfg)
+
+	var manifest map[string]string
+	readManifest(t, cfg, &manifest)
+	name := manifest["ui/app/main.js"]
+	if !strings.HasSuffix(name, ".mjs") {
+		t.Fatalf("the manifest names %q, not a .mjs output", name)
+	}
+	main := output(t, result, cfg.OutDir, name)
+	if !strings.HasSuffix(main, "//# sourceMappingURL="+name+".map\n") {
+		t.Errorf("%s doesn't link to %s.map:\n%s", name, name, main)
+	}
+	output(t, result, cfg.OutDir, name+".map")
+}
+
+func TestDefines(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `if (DEBUG) {
+				console.info("debug only");
+			}
+			console.info(VERSION);`,
+	})
+	cfg.Defines = map[string]string{
+		"DEBUG":   "false",
+		"VERSION": `"7.6.0"`,
+	}
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if strings.Contains(main, "debug only") {
+		t.Errorf("the DEBUG branch is not eliminated:\n%s", main)
+	}
+	if !strings.Contains(main, `"7.6.0"`) {
+		t.Errorf("VERSION is not replaced:\n%s", main)
+	}
+}
+
+func TestDefineEnv(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info(process.env.BUILD_ID,
+				process.env.UNSET_BUILD_VAR);`,
+	})
+	cfg.DefineEnv = "BUILD_ID, UNSET_BUILD_VAR"
+	t.Setenv("BUILD_ID", `ci-"42"`)
+	// restored at the end of the test like BUILD_ID
+	t.Setenv("UNSET_BUILD_VAR", "")
+	os.Unsetenv("UNSET_BUILD_VAR")
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, `console.info('ci-"42"',void 0)`) {
+		t.Errorf("the environment variables are not substituted:\n%s", main)
+	}
+
+	cfg.DefineEnvStrict = true
+	_, err := Run(cfg)
+	expected := "environment variable UNSET_BUILD_VAR given to -define-env " +
+		"is not set"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
+
+func TestSourcemap(t *testing.T) {
+	for _, test := range []struct {
+		sourcemap string
+		mapFile   bool
+		comment   string
+	}{
+		{"none", false, ""},
+		{"inline", false, "sourceMappingURL=data:application/json;base64,"},
+		{"linked", true, "sourceMappingURL=main.js.map"},
+		{"external", true, ""},
+		{"both", true, "sourceMappingURL=data:application/json;base64,"},
+	} {
+		t.Run(test.sourcemap, func(t *testing.T) {
+			cfg := newTestTree(t, map[string]string{
+				"ui/app/main.js": `console.info("main");`,
+			})
+			cfg.Sourcemap = test.sourcemap
+
+			result := mustBuild(t, cfg)
+
+			mapFile := false
+			for _, file := range result.OutputFiles {
+				mapFile = mapFile || strings.HasSuffix(file.Path, ".map")
+			}
+			if mapFile != test.mapFile {
+				t.Errorf("expected a .map file to be written: %v",
+					test.mapFile)
+			}
+			main := output(t, result, cfg.OutDir, "main.js")
+			hasComment := strings.Contains(main, "sourceMappingURL")
+			if test.comment == "" && hasComment ||
+				!strings.Contains(main, test.comment) {
+				t.Errorf("expected a %q comment:\n%s", test.comment, main)
+			}
+		})
+	}
+}
+
+func TestNoSourcemapComment(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("main");`,
+	})
+	cfg.Sourcemap = "linked"
+	cfg.NoSourcemapComment = true
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if strings.Contains(main, "sourceMappingURL") {
+		t.Errorf("main.js links to its source map:\n%s", main)
+	}
+	sourcemap := readFile(t, filepath.Join(cfg.OutDir, "main.js.map"))
+	if !strings.Contains(sourcemap, `"../ui/app/main.js"`) {
+		t.Errorf("unexpected main.js.map:\n%s", sourcemap)
+	}
+
+	cfg.Sourcemap = "inline"
+	if _, err := Run(cfg); err == nil {
+		t.Error("an inline source map is accepted")
+	}
+}
+
+func TestSourcemapInvalid(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("main");`,
+	})
+	cfg.Sourcemap = "maybe"
+
+	_, err := Run(cfg)
+
+	var optsErr *OptionsError
+	if !errors.As(err, &optsErr) {
+		t.Errorf("expected an *OptionsError, got %v", err)
+	}
+}
+
+func TestSourcesRoot(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("main");`,
+	})
+	cfg.Sourcemap = "external"
+	cfg.SourcesRoot = "https://github.com/couchbase/ns_server/blob/master/"
+
+	result := mustBuild(t, cfg)
+
+	var sourceMap struct {
+		SourceRoot string `json:"sourceRoot"`
+	}
+	data := output(t, result, cfg.OutDir, "main.js.map")
+	if err := json.Unmarshal([]byte(data), &sourceMap); err != nil {
+		t.Fatalf("can't parse the source map: %v\n%s", err, data)
+	}
+	if sourceMap.SourceRoot != cfg.SourcesRoot {
+		t.Errorf("unexpected sourceRoot %q, expected %q",
+			sourceMap.SourceRoot, cfg.SourcesRoot)
+	}
+}
+
+func TestSourcesRootNeedsSourcemap(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("main");`,
+	})
+	cfg.SourcesRoot = "https://github.com/couchbase/ns_server/blob/master/"
+
+	if _, err := Run(cfg); err == nil ||
+		!strings.Contains(err.Error(), "-sources-root needs source maps") {
+		t.Errorf("expected -sources-root to need source maps, got %v", err)
+	}
+}
+
+func TestInvalidOptionsLeaveOutDir(t *testing.T) {
+	for _, dualFormat := range []bool{false, true} {
+		cfg := newTestTree(t, map[string]string{
+			"ui/app/main.js": `console.info("main");`,
+		})
+		cfg.DualFormat = dualFormat
+		// the source maps are inline
+		cfg.MapDir = filepath.Join(cfg.InDir, "maps")
+
+		_, err := Run(cfg)
+
+		var optsErr *OptionsError
+		if !errors.As(err, &optsErr) {
+			t.Errorf("DualFormat %v: expected an *OptionsError, got %v",
+				dualFormat, err)
+		}
+		if _, err := os.Stat(cfg.OutDir); !os.IsNotExist(err) {
+			t.Errorf("DualFormat %v: the out dir is created: %v",
+				dualFormat, err)
+		}
+	}
+}
+
+func TestMinifyToggles(t *testing.T) {
+	on, off := true, false
+	for _, test := range []struct {
+		name                 string
+		minify               bool
+		whitespace, syntax   *bool
+		identifiers          *bool
+		spaced, long, mangle bool
+	}{
+		{"minify=false", false, nil, nil, nil, true, true, false},
+		{"minify", true, nil, nil, nil, false, false, true},
+		{"whitespace", false, &on, nil, nil, false, true, false},
+		{"syntax", false, nil, &on, nil, true, false, false},
+		{"identifiers", false, nil, nil, &on, true, true, true},
+		{"minify without whitespace", true, &off, nil, nil, true, false,
+			true},
+		{"minify without syntax", true, nil, &off, nil, false, true, true},
+		{"minify without identifiers", true, nil, nil, &off, false, false,
+			false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := newTestTree(t, map[string]string{
+				"ui/app/main.js": `function add(firstNumber, secondNumber) {
+						return firstNumber + secondNumber;
+					}
+					console.info(add(1, 2), true);`,
+			})
+			cfg.KeepNames = false
+			cfg.Minify = test.minify
+			cfg.MinifyWhitespace = test.whitespace
+			cfg.MinifySyntax = test.syntax
+			cfg.MinifyIdentifiers = test.identifiers
+
+			result := mustBuild(t, cfg)
+
+			main := output(t, result, cfg.OutDir, "main.js")
+			if spaced := strings.Contains(main, "\n  "); spaced != test.spaced {
+				t.Errorf("expected whitespace to be kept: %v", test.spaced)
+			}
+			// minified syntax writes true as !0
+			if long := strings.Contains(main, "true"); long != test.long {
+				t.Errorf("expected the syntax to be kept: %v", test.long)
+			}
+			if mangle := !strings.Contains(main, "firstNumber"); mangle !=
+				test.mangle {
+				t.Errorf("expected identifiers to be minified: %v",
+					test.mangle)
+			}
+			if t.Failed() {
+				t.Logf("main.js:\n%s", main)
+			}
+		})
+	}
+}
+
+func TestMangleProps(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `const counter = {
+				count_: 0,
+				public_: "kept",
+				label: "counter",
+			};
+			counter.count_++;
+			console.info(counter.count_, counter.public_, counter.label);`,
+	})
+	cfg.MangleProps = "_$"
+	cfg.ReserveProps = "^public_$"
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if strings.Contains(main, "count_") {
+		t.Errorf("count_ is not mangled:\n%s", main)
+	}
+	for _, prop := range []string{"public_", "label"} {
+		if !strings.Contains(main, prop) {
+			t.Errorf("%s is mangled:\n%s", prop, main)
+		}
+	}
+}
+
+func TestTSXEntryPoint(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.tsx": `import { greeting } from "./greeting";
+			const title: string = greeting("world");
+			console.info(<h1 className="title">{title}</h1>);`,
+		"ui/app/greeting.ts": `export function greeting(name: string): string {
+				return "hello " + name;
+			}`,
+	})
+	cfg.EntryPoints = []string{"ui/app/main.tsx"}
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	for _, text := range []string{`React.createElement("h1"`, `"hello "`} {
+		if !strings.Contains(main, text) {
+			t.Errorf("%q is missing from main.js:\n%s", text, main)
+		}
+	}
+}
+
+func TestJSXFactory(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.jsx": `console.info(<><b>hello</b></>);`,
+	})
+	cfg.EntryPoints = []string{"ui/app/main.jsx"}
+	cfg.JSXFactory = "h"
+	cfg.JSXFragment = "Fragment"
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, `h(Fragment,null,h("b",null,"hello"))`) {
+		t.Errorf("the custom factory is not used:\n%s", main)
+	}
+
+	cfg.JSX = "preserve"
+	result = mustBuild(t, cfg)
+
+	main = output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, "<b>hello</b>") {
+		t.Errorf("the JSX is not preserved:\n%s", main)
+	}
+}
+
+func TestTsconfigPaths(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/tsconfig.build.json": `{"compilerOptions": {
+			"baseUrl": ".",
+			"paths": {"@components/*": ["app/components/*"]}
+		}}`,
+		"ui/app/components/button.ts": `
+			export const button: string = "the button";`,
+		"ui/app/main.ts": `import { button } from "@components/button";
+			console.info(button);`,
+	})
+	cfg.EntryPoints = []string{"ui/app/main.ts"}
+	cfg.Tsconfig = "ui/tsconfig.build.json"
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, `"the button"`) {
+		t.Errorf("the path alias is not resolved:\n%s", main)
+	}
+}
+
+func TestBannerFooter(t *testing.T) {
+	const banner = "/*\n * @copyright 2026-Present Couchbase, Inc.\n */"
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("main");`,
+		"footer.txt":     "// the end",
+	})
+	cfg.Banner = banner
+	cfg.Footer = "@" + filepath.Join(cfg.InDir, "footer.txt")
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.HasPrefix(main, banner+"\n") {
+		t.Errorf("main.js doesn't start with the banner:\n%s", main)
+	}
+	if !strings.HasSuffix(main, "// the end\n") {
+		t.Errorf("main.js doesn't end with the footer file:\n%s", main)
+	}
+}
+
+func TestLegalComments(t *testing.T) {
+	const legal = "/*! preserved */"
+	files := map[string]string{
+		"ui/app/main.js": `import { lib } from "./lib.js";
+			console.info(lib);`,
+		"ui/app/lib.js": legal + `
+			export const lib = "lib";`,
+	}
+
+	tests := []struct {
+		mode   string
+		inline bool
+		eof    bool
+		link   bool
+		file   bool
+	}{
+		{mode: "none"},
+		{mode: "inline", inline: true},
+		{mode: "eof", eof: true},
+		{mode: "linked", link: true, file: true},
+		{mode: "external", file: true},
+	}
+	for _, test := range tests {
+		t.Run(test.mode, func(t *testing.T) {
+			cfg := newTestTree(t, files)
+			cfg.LegalComments = test.mode
+
+			result := mustBuild(t, cfg)
+
+			main := output(t, result, cfg.OutDir, "main.js")
+			comment := strings.Index(main, legal)
+			code := strings.Index(main, `"lib"`)
+			if inline := comment >= 0 && comment < code; inline != test.inline {
+				t.Errorf("inline comment: %v, expected %v", inline, test.inline)
+			}
+			if eof := comment > code; eof != test.eof {
+				t.Errorf("end of file comment: %v, expected %v", eof, test.eof)
+			}
+			link := strings.Contains(main, "main.js.LEGAL.txt")
+			if link != test.link {
+				t.Errorf("link to the legal file: %v, expected %v", link,
+					test.link)
+			}
+			if t.Failed() {
+				t.Logf("main.js:\n%s", main)
+			}
+
+			path := filepath.Join(cfg.OutDir, "main.js.LEGAL.txt")
+			_, err := os.Stat(path)
+			if file := err == nil; file != test.file {
+				t.Fatalf("%s written: %v, expected %v", path, file, test.file)
+			}
+			if test.file && !strings.Contains(readFile(t, path), legal) {
+				t.Errorf("%s doesn't have the comment", path)
+			}
+		})
+	}
+}
+
+func TestDataURLLoader(t *testing.T) {
+	// the smallest valid PNG, a single transparent pixel
+	pixel, err := base64.StdEncoding.DecodeString("iVBORw0KGgoAAAANSUhEUg" +
+		"AAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5" +
+		"ErkJggg==")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := newTestTree(t, map[string]string{
+		"ui/importmap.json": `{"imports": {
+			"icons/": "./web_modules/icons/"
+		}}`,
+		"ui/web_modules/icons/pixel.png": string(pixel),
+		"ui/app/main.js": `import pixel from "icons/pixel.png";
+			console.info(pixel);`,
+	})
+	cfg.Loaders[".png"] = "dataurl"
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, `"data:image/png;base64,iVBORw0KGgo`) {
+		t.Errorf("pixel.png is not inlined as a data URL:\n%s", main)
+	}
+	if len(result.OutputFiles) != 1 {
+		t.Errorf("pixel.png is also output: %v", jsOutputs(result))
+	}
+}
+
+func TestDefaultLoader(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/notes.weirdext": "some notes",
+		"ui/app/main.js": `import notes from "./notes.weirdext";
+			console.info(notes);`,
+	})
+
+	result := mustRun(t, cfg)
+	if !hasMessage(result.Errors, `No loader is configured for ".weirdext"`) {
+		t.Errorf("expected the unknown extension to fail the build, got %v",
+			messageTexts(result.Errors))
+	}
+
+	cfg.DefaultLoader = "text"
+	result = mustBuild(t, cfg)
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, `"some notes"`) {
+		t.Errorf("notes.weirdext is not loaded as text:\n%s", main)
+	}
+}
+
+func TestPublicPath(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/logo.png": "not really a png",
+		"ui/app/main.js": `import logo from "./logo.png";
+			console.info(logo);`,
+	})
+	cfg.Loaders[".png"] = "file"
+	cfg.AssetNames = "[name]"
+	cfg.PublicPath = "/ui/v2"
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, `"/ui/v2/logo.png"`) {
+		t.Errorf("the asset URL isn't under the public path:\n%s", main)
+	}
+	output(t, result, cfg.OutDir, "logo.png")
+}
+
+func TestDrop(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `export function check(x) {
+				if (!x) {
+					console.error("no x");
+					debugger;
+				}
+				return x;
+			}`,
+	})
+	cfg.Drop = "console,debugger"
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	for _, text := range []string{"console.error", "debugger"} {
+		if strings.Contains(main, text) {
+			t.Errorf("%s is not dropped:\n%s", text, main)
+		}
+	}
+}
+
+func TestKeepConsole(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.log("startup banner");`,
+	})
+
+	for _, keep := range []bool{false, true} {
+		cfg.KeepConsole = keep
+
+		result := mustBuild(t, cfg)
+
+		main := output(t, result, cfg.OutDir, "main.js")
+		if kept := strings.Contains(main, "startup banner"); kept != keep {
+			t.Errorf("KeepConsole %v: console.log kept %v:\n%s", keep,
+				kept, main)
+		}
+	}
+
+	cfg.Drop = "console"
+	_, err := Run(cfg)
+	var optsErr *OptionsError
+	if !errors.As(err, &optsErr) {
+		t.Errorf("expected an *OptionsError, got %v", err)
+	}
+}
+
+func TestIgnoreAnnotations(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `/* @__PURE__ */ registerPlugin("needed");`,
+	})
+
+	for _, ignore := range []bool{false, true} {
+		cfg.IgnoreAnnotations = ignore
+
+		result := mustBuild(t, cfg)
+
+		main := output(t, result, cfg.OutDir, "main.js")
+		if kept := strings.Contains(main, "registerPlugin"); kept != ignore {
+			t.Errorf("IgnoreAnnotations %v: the call is kept %v:\n%s",
+				ignore, kept, main)
+		}
+	}
+}
+
+func TestPureFunctions(t *testing.T) {
+	files := map[string]string{
+		"ui/app/main.js": `log.debug("custom pure");
+			audit("not pure");
+			console.log("default pure");`,
+	}
+
+	for _, test := range []struct {
+		pure    []string
+		removed []string
+		kept    []string
+	}{
+		{
+			[]string{"log.debug"},
+			[]string{"custom pure", "default pure"},
+			[]string{"not pure"},
+		},
+		{
+			[]string{PureNone, "log.debug"},
+			[]string{"custom pure"},
+			[]string{"not pure", "default pure"},
+		},
+	} {
+		cfg := newTestTree(t, files)
+		cfg.PureFunctions = test.pure
+
+		result := mustBuild(t, cfg)
+
+		main := output(t, result, cfg.OutDir, "main.js")
+		for _, text := range test.removed {
+			if strings.Contains(main, text) {
+				t.Errorf("%v: the %q call is not removed:\n%s", test.pure,
+					text, main)
+			}
+		}
+		for _, text := range test.kept {
+			if !strings.Contains(main, text) {
+				t.Errorf("%v: the %q call is removed:\n%s", test.pure, text,
+					main)
+			}
+		}
+	}
+}
+
+func TestCSSEntryPoint(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("main");`,
+		"ui/css/app.css": `@import "./base.css";
+			.title {
+				color: #ff0000;
+			}`,
+		"ui/css/base.css": `body {
+				margin: 0px;
+			}`,
+	})
+	cfg.EntryPoints = []string{"ui/app/main.js", "ui/css/app.css"}
+
+	result := mustBuild(t, cfg)
+
+	expected := "body{margin:0}.title{color:red}\n"
+	if css := output(t, result, cfg.OutDir, "css/app.css"); css != expected {
+		t.Errorf("unexpected app.css %q, expected %q", css, expected)
+	}
+	for _, file := range result.OutputFiles {
+		if strings.HasSuffix(file.Path, "base.css") {
+			t.Errorf("the imported stylesheet is output as %s", file.Path)
+		}
+	}
+}
+
+func TestPreserveSymlinks(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"store/lib/index.js": `import { dep } from "dep";
+			export const lib = dep;`,
+		"store/node_modules/dep/index.js": `export const dep = "real dep";`,
+		"ui/app/node_modules/dep/index.js": `
+			export const dep = "linked dep";`,
+		"ui/app/main.js": `import { lib } from "lib"; console.info(lib);`,
+	})
+	// lib and dep are resolved from node_modules
+	cfg.StrictImportMap = false
+	err := os.Symlink(filepath.Join(cfg.InDir, "store", "lib"),
+		filepath.Join(cfg.InDir, "ui", "app", "node_modules", "lib"))
+	if err != nil {
+		t.Skipf("can't create the symlink: %v", err)
+	}
+
+	for _, test := range []struct {
+		preserve bool
+		dep      string
+	}{
+		{true, "linked dep"},
+		{false, "real dep"},
+	} {
+		cfg.PreserveSymlinks = test.preserve
+
+		result := mustBuild(t, cfg)
+
+		main := output(t, result, cfg.OutDir, "main.js")
+		if !strings.Contains(main, `"`+test.dep+`"`) {
+			t.Errorf("%q isn't imported with PreserveSymlinks %v:\n%s",
+				test.dep, test.preserve, main)
+		}
+	}
+}
+
+func TestCharset(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("Größe ✓");`,
+	})
+
+	for _, test := range []struct {
+		charset  string
+		expected string
+	}{
+		{"ascii", `"Gr\xF6\xDFe \u2713"`},
+		{"utf8", `"Größe ✓"`},
+	} {
+		cfg.Charset = test.charset
+
+		result := mustBuild(t, cfg)
+
+		main := output(t, result, cfg.OutDir, "main.js")
+		if !strings.Contains(main, test.expected) {
+			t.Errorf("%s: %s is missing from main.js:\n%s", test.charset,
+				test.expected, main)
+		}
+	}
+}
+
+func TestInject(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/shims/legacy.js": `export const legacyGlobal = {name: "shimmed"};`,
+		"ui/app/main.js":     `console.info(legacyGlobal.name);`,
+	})
+	cfg.Injects = []string{"ui/shims/legacy.js"}
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, `{name:"shimmed"}`) {
+		t.Errorf("the global is not resolved to the shim:\n%s", main)
+	}
+}
+
+func TestResolveExtensions(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/lib.mjs": `export const lib = "from the mjs";`,
+		"ui/app/main.js": `import { lib } from "./lib"; console.info(lib);`,
+	})
+
+	cfg.ResolveExtensions = ".js,.mjs"
+	result := mustBuild(t, cfg)
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, "from the mjs") {
+		t.Errorf("lib.mjs is not bundled:\n%s", main)
+	}
+
+	cfg.ResolveExtensions = ".js,.json"
+	result = mustRun(t, cfg)
+	if !hasMessage(result.Errors, `Could not resolve "./lib"`) {
+		t.Errorf("expected ./lib not to resolve, got %v",
+			messageTexts(result.Errors))
+	}
+}
+
+func TestFileLoaders(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/importmap.json": `{"imports": {
+			"icons/": "./web_modules/icons/"
+		}}`,
+		"ui/web_modules/icons/close.svg": `<svg id="close"/>`,
+		"ui/app/images/logo.svg":         `<svg id="logo"/>`,
+		"ui/app/main.js": `import close from "icons/close.svg";
+			import logo from "./images/logo.svg";
+			console.info(close, logo);`,
+	})
+	cfg.Loaders[".svg"] = "file"
+	cfg.FileLoaders = map[string]string{"ui/web_modules/icons/*.svg": "text"}
+	cfg.AssetNames = "[name]"
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, `'<svg id="close"/>'`) {
+		t.Errorf("close.svg is not inlined as text:\n%s", main)
+	}
+	if strings.Contains(main, `id="logo"`) ||
+		!strings.Contains(main, `"./logo.svg"`) {
+		t.Errorf("logo.svg is not loaded as a file:\n%s", main)
+	}
+	output(t, result, cfg.OutDir, "logo.svg")
+}
+
+func TestConditions(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/node_modules/pkg/package.json": `{"exports": {
+			"development": "./dev.js",
+			"browser": "./browser.js",
+			"default": "./default.js"
+		}}`,
+		"ui/app/node_modules/pkg/dev.js":     `export default "dev build";`,
+		"ui/app/node_modules/pkg/browser.js": `export default "browser build";`,
+		"ui/app/node_modules/pkg/default.js": `export default "default build";`,
+		"ui/app/main.js": `import pkg from "pkg";
+			console.info(pkg);`,
+	})
+	// pkg is resolved from node_modules
+	cfg.StrictImportMap = false
+
+	for _, test := range []struct {
+		conditions string
+		expected   string
+	}{
+		{"", "browser build"},
+		{"development", "dev build"},
+	} {
+		cfg.Conditions = test.conditions
+
+		result := mustBuild(t, cfg)
+
+		main := output(t, result, cfg.OutDir, "main.js")
+		if !strings.Contains(main, test.expected) {
+			t.Errorf("conditions %q: expected the %s:\n%s", test.conditions,
+				test.expected, main)
+		}
+	}
+}
+
+func TestPlatform(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/node_modules/pkg/package.json": `{"exports": {
+			"browser": "./browser.js",
+			"default": "./default.js"
+		}}`,
+		"ui/app/node_modules/pkg/browser.js": `export default "browser build";`,
+		"ui/app/node_modules/pkg/default.js": `export default "default build";`,
+		"ui/app/main.js": `import pkg from "pkg";
+			console.info(pkg);`,
+	})
+	// pkg is resolved from node_modules
+	cfg.StrictImportMap = false
+
+	for _, test := range []struct {
+		platform string
+		expected string
+	}{
+		{"browser", "browser build"},
+		{"neutral", "default build"},
+	} {
+		cfg.Platform = test.platform
+
+		result := mustBuild(t, cfg)
+
+		main := output(t, result, cfg.OutDir, "main.js")
+		if !strings.Contains(main, test.expected) {
+			t.Errorf("%s platform: expected the %s:\n%s", test.platform,
+				test.expected, main)
+		}
+	}
+}
+
+func TestMainFields(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/node_modules/pkg/package.json": `{
+			"module": "./esm.js",
+			"main": "./cjs.js"
+		}`,
+		"ui/app/node_modules/pkg/esm.js": `export default "module build";`,
+		"ui/app/node_modules/pkg/cjs.js": `module.exports = "main build";`,
+		"ui/app/main.js": `import pkg from "pkg";
+			console.info(pkg);`,
+	})
+	// pkg is resolved from node_modules
+	cfg.StrictImportMap = false
+
+	for _, test := range []struct {
+		mainFields string
+		expected   string
+	}{
+		{"module,main", "module build"},
+		{"main,module", "main build"},
+	} {
+		cfg.MainFields = test.mainFields
+
+		result := mustBuild(t, cfg)
+
+		main := output(t, result, cfg.OutDir, "main.js")
+		if !strings.Contains(main, test.expected) {
+			t.Errorf("main fields %s: expected the %s:\n%s",
+				test.mainFields, test.expected, main)
+		}
+	}
+}
+
+func TestAliases(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/importmap.json":     `{"imports": {"lib": "./web_modules/lib.js"}}`,
+		"ui/web_modules/lib.js": `export default "mapped lib";`,
+		"ui/vendor/lib-fork.js": `export default "patched fork";`,
+		"ui/app/main.js": `import lib from "lib";
+			console.info(lib);`,
+	})
+	cfg.Aliases = map[string]string{"lib": "./ui/vendor/lib-fork.js"}
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, "patched fork") ||
+		strings.Contains(main, "mapped lib") {
+		t.Errorf("the alias doesn't take precedence over the map:\n%s",
+			main)
+	}
+}
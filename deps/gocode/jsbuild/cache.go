@@ -0,0 +1,370 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// The esbuild API keeps its parse cache in memory only, so it can't be
+// reused by a later process. -cache-dir caches whole builds instead: if
+// neither the config nor any of the inputs of the previous build changed,
+// its outputs are copied from the cache and esbuild doesn't run at all.
+//
+// The cache dir holds one build-<key>.json record per config, listing the
+// inputs and outputs of the last successful build, and the contents of the
+// outputs under blobs/, named by their sha256.
+
+type cacheRecord struct {
+	Inputs  []cacheInput  `json:"inputs"`
+	Outputs []cacheOutput `json:"outputs"`
+}
+
+type cacheInput struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	Hash    string    `json:"hash"`
+}
+
+type cacheOutput struct {
+	Path string `json:"path"`
+	Blob string `json:"blob"`
+}
+
+// writtenFiles tracks the files the plugins write themselves, which aren't
+// among the outputs of esbuild: the source maps -write-map-dir moves, by
+// their paths in the out dir, and the modules -no-minify-glob copies. A nil
+// *writtenFiles tracks nothing.
+type writtenFiles struct {
+	mu     sync.Mutex
+	moved  map[string]string
+	copied map[string]bool
+}
+
+func newWrittenFiles() *writtenFiles {
+	return &writtenFiles{
+		moved:  make(map[string]string),
+		copied: make(map[string]bool),
+	}
+}
+
+func (w *writtenFiles) move(from, to string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.moved[from] = to
+}
+
+func (w *writtenFiles) copy(path string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.copied[path] = true
+}
+
+// paths returns the paths of outputs, the esbuild outputs, where they are
+// now, followed by the copied files.
+func (w *writtenFiles) paths(outputs []api.OutputFile) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var paths []string
+	for _, file := range outputs {
+		path := file.Path
+		if to, ok := w.moved[path]; ok {
+			path = to
+		}
+		paths = append(paths, path)
+	}
+	var copied []string
+	for path := range w.copied {
+		copied = append(copied, path)
+	}
+	sort.Strings(copied)
+	return append(paths, copied...)
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// getCacheRecordPath returns the path of the cache record for cfg, which is
+// named after a hash of the whole config, the defines taken from the
+// environment and the tool version.
+func getCacheRecordPath(cfg Options) (string, error) {
+	defines, err := getDefines(cfg)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(struct {
+		Config  Options
+		Defines map[string]string
+	}{cfg, defines})
+	if err != nil {
+		return "", err
+	}
+	key := hashBytes(append(data, Version...))
+	return filepath.Join(cfg.CacheDir, "build-"+key[:16]+".json"), nil
+}
+
+func getBlobPath(cacheDir, blob string) string {
+	return filepath.Join(cacheDir, "blobs", blob)
+}
+
+func newCacheInput(path string) (cacheInput, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return cacheInput{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cacheInput{}, err
+	}
+	return cacheInput{
+		Path:    path,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Hash:    hashBytes(data),
+	}, nil
+}
+
+// isUnchanged reports whether the input still has the recorded contents.
+// The contents are only hashed again when the mtime differs.
+func (input cacheInput) isUnchanged() bool {
+	info, err := os.Stat(input.Path)
+	if err != nil || info.Size() != input.Size {
+		return false
+	}
+	if info.ModTime().Equal(input.ModTime) {
+		return true
+	}
+	data, err := os.ReadFile(input.Path)
+	return err == nil && hashBytes(data) == input.Hash
+}
+
+// getCacheInputs returns the files a build depends on besides its config:
+// the inputs listed in the metafile, the import maps, the tsconfig and the
+// banner and footer files.
+func getCacheInputs(cfg Options, workingDir string,
+	meta metafile) []string {
+	var paths []string
+	for path := range meta.Inputs {
+		// skip inputs from other namespaces, like "<stdin>"
+		if strings.Contains(path, ":") || strings.HasPrefix(path, "<") {
+			continue
+		}
+		paths = append(paths, filepath.Join(workingDir, path))
+	}
+	paths = append(paths, importMapFiles(cfg.ImportMapPaths)...)
+	if cfg.Tsconfig != "" {
+		paths = append(paths, inDirPath(cfg.InDir, cfg.Tsconfig))
+	}
+	for _, text := range []string{cfg.Banner, cfg.Footer} {
+		if path, isFile := strings.CutPrefix(text, "@"); isFile {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// getCacheOutputs returns the files written by a build: the esbuild outputs
+// and those the plugins copied, as tracked by written, followed by the files
+// written by the post-build plugins.
+func getCacheOutputs(cfg Options, result *api.BuildResult,
+	written *writtenFiles) []string {
+	var paths []string
+	for _, path := range written.paths(result.OutputFiles) {
+		paths = append(paths, path)
+		if cfg.Compress == "" || !isCompressible(path) {
+			continue
+		}
+		for _, c := range compressors {
+			if _, err := os.Stat(path + c.ext); err == nil {
+				paths = append(paths, path+c.ext)
+			}
+		}
+	}
+	if cfg.Metafile != "" {
+		paths = append(paths, cfg.Metafile)
+	}
+	if cfg.GraphOut != "" {
+		paths = append(paths, cfg.GraphOut)
+	}
+	if manifestPath := getManifestPath(cfg); manifestPath != "" {
+		paths = append(paths, manifestPath)
+	}
+	if cfg.Integrity {
+		paths = append(paths, filepath.Join(cfg.OutDir, IntegrityFileName))
+	}
+	return paths
+}
+
+func writeCacheRecord(cfg Options, workingDir string,
+	result *api.BuildResult, written *writtenFiles) error {
+	recordPath, err := getCacheRecordPath(cfg)
+	if err != nil {
+		return err
+	}
+	meta, err := parseMetafile(result.Metafile)
+	if err != nil {
+		return err
+	}
+
+	var record cacheRecord
+	for _, path := range getCacheInputs(cfg, workingDir, meta) {
+		input, err := newCacheInput(path)
+		if err != nil {
+			return err
+		}
+		record.Inputs = append(record.Inputs, input)
+	}
+
+	if err := os.MkdirAll(getBlobPath(cfg.CacheDir, ""), 0755); err != nil {
+		return err
+	}
+	for _, path := range getCacheOutputs(cfg, result, written) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		blob := hashBytes(data)
+		err = os.WriteFile(getBlobPath(cfg.CacheDir, blob), data, 0644)
+		if err != nil {
+			return err
+		}
+		record.Outputs = append(record.Outputs, cacheOutput{path, blob})
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(recordPath, data, 0644)
+}
+
+// restoreFromCache writes the outputs of the cached build for cfg, if
+// there is one and its inputs are unchanged, and returns them.
+func restoreFromCache(cfg Options) ([]api.OutputFile, bool) {
+	recordPath, err := getCacheRecordPath(cfg)
+	if err != nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(recordPath)
+	if err != nil {
+		return nil, false
+	}
+	var record cacheRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		logWarning(cfg.Quiet, "Warning: ignoring cache record %s: %s\n",
+			recordPath, err.Error())
+		return nil, false
+	}
+
+	for _, input := range record.Inputs {
+		if !input.isUnchanged() {
+			return nil, false
+		}
+	}
+
+	var files []api.OutputFile
+	for _, output := range record.Outputs {
+		contents, err := os.ReadFile(getBlobPath(cfg.CacheDir, output.Blob))
+		if err != nil || hashBytes(contents) != output.Blob {
+			return nil, false
+		}
+		files = append(files, api.OutputFile{
+			Path:     output.Path,
+			Contents: contents,
+		})
+	}
+
+	for _, file := range files {
+		current, err := os.ReadFile(file.Path)
+		if err == nil && bytes.Equal(current, file.Contents) {
+			continue
+		}
+		err = os.MkdirAll(filepath.Dir(file.Path), 0755)
+		if err == nil {
+			err = os.WriteFile(file.Path, file.Contents, 0644)
+		}
+		if err != nil {
+			logWarning(cfg.Quiet, "Warning: cannot restore %s from cache: "+
+				"%s\n", file.Path, err.Error())
+			return nil, false
+		}
+	}
+	return files, true
+}
+
+// cachedBuild runs the build, unless its outputs can be restored from the
+// cache in -cache-dir. The plugins don't run then, but the -post-build
+// command does, as what it does is up to it, unless the restored outputs
+// exceed -max-size, which Run checks them against. The plugins that only
+// report on the build can't be used with -cache-dir.
+func cachedBuild(cfg Options, opts api.BuildOptions) Result {
+	if cfg.CacheDir == "" {
+		return newResult(api.Build(opts))
+	}
+	files, ok := restoreFromCache(cfg)
+	if !ok {
+		return newResult(api.Build(opts))
+	}
+	log.Printf("Inputs unchanged, restored %d file(s) from %s\n",
+		len(files), cfg.CacheDir)
+	result := Result{OutputFiles: files}
+	if cfg.PostBuild != "" && !overSizeBudget(cfg, files) {
+		if err := runPostBuild(cfg.PostBuild, cfg.OutDir); err != nil {
+			addError(cfg, &result, err.Error())
+		}
+	}
+	return result
+}
+
+// getCachePlugin records the inputs and outputs of every successful build
+// in -cache-dir, along with the files the plugins tracked in written wrote.
+// It has to be the last plugin, so that the files written by the other
+// post-build plugins exist by the time it runs.
+func getCachePlugin(cfg Options, written *writtenFiles) api.Plugin {
+	return api.Plugin{
+		Name: "Cache",
+		Setup: func(build api.PluginBuild) {
+			workingDir := getWorkingDir(build.InitialOptions)
+
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				if len(result.Errors) > 0 {
+					return api.OnEndResult{}, nil
+				}
+				err := writeCacheRecord(cfg, workingDir, result, written)
+				if err != nil {
+					return api.OnEndResult{}, fmt.Errorf("cannot write "+
+						"cache record: %s", err.Error())
+				}
+				return api.OnEndResult{}, nil
+			})
+		},
+	}
+}
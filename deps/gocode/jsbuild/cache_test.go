@@ -0,0 +1,148 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCacheDir(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/importmap.json":     `{"imports": {"lib": "./web_modules/lib.js"}}`,
+		"ui/web_modules/lib.js": `export const lib = "lib";`,
+		"ui/app/main.js": `import { lib } from "lib";
+			console.info(lib);`,
+	})
+	cfg.CacheDir = filepath.Join(cfg.InDir, "cache")
+	cfg.Sourcemap = "linked"
+	logged := captureLog(t)
+
+	first := mustBuild(t, cfg)
+	built := make(map[string]string)
+	for _, file := range first.OutputFiles {
+		built[file.Path] = readFile(t, file.Path)
+	}
+	if err := os.RemoveAll(cfg.OutDir); err != nil {
+		t.Fatal(err)
+	}
+
+	second := mustBuild(t, cfg)
+
+	if !strings.Contains(logged.String(), "Inputs unchanged, restored 2 "+
+		"file(s) from "+cfg.CacheDir) {
+		t.Errorf("the second build isn't restored from the cache:\n%s",
+			logged)
+	}
+	if len(second.OutputFiles) != len(built) {
+		t.Errorf("expected %d outputs, got %d", len(built),
+			len(second.OutputFiles))
+	}
+	for path, contents := range built {
+		if restored := readFile(t, path); restored != contents {
+			t.Errorf("%s differs from the first build:\n%s\nvs\n%s", path,
+				restored, contents)
+		}
+	}
+}
+
+// restoredBuild builds cfg, wipes dirs and builds it again, checking that
+// the second build restores all the files of the first one from the cache.
+func restoredBuild(t *testing.T, cfg Options, files []string,
+	dirs ...string) Result {
+	t.Helper()
+	logged := captureLog(t)
+	mustRun(t, cfg)
+	built := make(map[string]string)
+	for _, path := range files {
+		built[path] = readFile(t, path)
+	}
+	for _, dir := range dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result := mustRun(t, cfg)
+
+	if !strings.Contains(logged.String(), "Inputs unchanged, restored "+
+		fmt.Sprint(len(files))+" file(s) from "+cfg.CacheDir) {
+		t.Errorf("the second build isn't restored from the cache:\n%s",
+			logged)
+	}
+	for path, contents := range built {
+		if restored := readFile(t, path); restored != contents {
+			t.Errorf("%s differs from the first build:\n%s\nvs\n%s", path,
+				restored, contents)
+		}
+	}
+	return result
+}
+
+func TestCacheDirWithMapDir(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/legacy/template.js": `export const render = () => "legacy";`,
+		"ui/app/main.js": `import { render } from "./legacy/template.js";
+			console.info(render());`,
+	})
+	cfg.CacheDir = filepath.Join(cfg.InDir, "cache")
+	cfg.Sourcemap = "linked"
+	cfg.MapDir = filepath.Join(cfg.InDir, "maps")
+	cfg.PublicPath = "/ui"
+	cfg.NoMinifyGlobs = []string{"ui/app/legacy/*.js"}
+
+	restoredBuild(t, cfg, []string{
+		filepath.Join(cfg.OutDir, "main.js"),
+		filepath.Join(cfg.MapDir, "main.js.map"),
+		filepath.Join(cfg.OutDir, "ui", "app", "legacy", "template.js"),
+	}, cfg.OutDir, cfg.MapDir)
+}
+
+func TestCacheDirMaxSize(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("over the budget");`,
+	})
+	cfg.CacheDir = filepath.Join(cfg.InDir, "cache")
+	cfg.MaxSize = "10"
+
+	result := restoredBuild(t, cfg,
+		[]string{filepath.Join(cfg.OutDir, "main.js")}, cfg.OutDir)
+
+	if !result.OverBudget {
+		t.Errorf("the restored build isn't checked against -max-size")
+	}
+}
+
+func TestCacheDirRejectsReports(t *testing.T) {
+	for flag, set := range map[string]func(*Options){
+		"-summary":       func(cfg *Options) { cfg.Summary = true },
+		"-timing":        func(cfg *Options) { cfg.Timing = true },
+		"-report-unused": func(cfg *Options) { cfg.ReportUnused = true },
+		"-detect-cycles": func(cfg *Options) { cfg.DetectCycles = true },
+		"-detect-duplicates": func(cfg *Options) {
+			cfg.DetectDuplicates = true
+		},
+	} {
+		cfg := newTestTree(t, map[string]string{
+			"ui/app/main.js": `console.info("main");`,
+		})
+		cfg.CacheDir = filepath.Join(cfg.InDir, "cache")
+		set(&cfg)
+
+		_, err := Run(cfg)
+
+		if err == nil || !strings.Contains(err.Error(),
+			"-cache-dir can't be used with "+flag) {
+			t.Errorf("%s: expected it to be rejected, got %v", flag, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+type compressor struct {
+	ext       string
+	newWriter func(io.Writer) io.WriteCloser
+}
+
+var compressors = map[string]compressor{
+	"gzip": {".gz", func(w io.Writer) io.WriteCloser {
+		// can only fail for an invalid level
+		gw, _ := gzip.NewWriterLevel(w, gzip.BestCompression)
+		return gw
+	}},
+	"brotli": {".br", func(w io.Writer) io.WriteCloser {
+		return brotli.NewWriterLevel(w, brotli.BestCompression)
+	}},
+}
+
+// parseCompressors parses a comma separated list of "gzip" and "brotli".
+func parseCompressors(names string) ([]compressor, error) {
+	var result []compressor
+	for _, name := range strings.Split(names, ",") {
+		c, ok := compressors[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown compression '%s', valid "+
+				"values are: gzip, brotli", name)
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+func isCompressible(path string) bool {
+	return isJSOutput(path) || filepath.Ext(path) == ".css"
+}
+
+// compressFile writes path+c.ext with the compressed contents of path, if
+// that turns out smaller than the original.
+func compressFile(path string, c compressor) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	w := c.newWriter(&buf)
+	if _, err := w.Write(contents); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	if buf.Len() >= len(contents) {
+		// a stale variant from an earlier build would be served instead
+		err := os.Remove(path + c.ext)
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return err
+	}
+	return os.WriteFile(path+c.ext, buf.Bytes(), 0644)
+}
+
+// getCompressPlugin writes pre-compressed variants of the .js and .css
+// outputs of every successful build next to them.
+func getCompressPlugin(enabled []compressor) api.Plugin {
+	return api.Plugin{
+		Name: "Compress",
+		Setup: func(build api.PluginBuild) {
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				if len(result.Errors) > 0 {
+					return api.OnEndResult{}, nil
+				}
+				for _, file := range result.OutputFiles {
+					if !isCompressible(file.Path) {
+						continue
+					}
+					for _, c := range enabled {
+						if err := compressFile(file.Path, c); err != nil {
+							return api.OnEndResult{}, err
+						}
+					}
+				}
+				return api.OnEndResult{}, nil
+			})
+		},
+	}
+}
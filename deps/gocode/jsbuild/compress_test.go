@@ -0,0 +1,71 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestCompress(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `
+			console.info("` + strings.Repeat("compressible ", 100) + `");`,
+	})
+	cfg.Compress = "gzip,brotli"
+
+	mustBuild(t, cfg)
+
+	main := readFile(t, filepath.Join(cfg.OutDir, "main.js"))
+	for ext, newReader := range map[string]func(io.Reader) (io.Reader, error){
+		".gz": func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		},
+		".br": func(r io.Reader) (io.Reader, error) {
+			return brotli.NewReader(r), nil
+		},
+	} {
+		compressed := readFile(t, filepath.Join(cfg.OutDir, "main.js"+ext))
+		if len(compressed) >= len(main) {
+			t.Errorf("main.js%s is not smaller than main.js", ext)
+		}
+		r, err := newReader(strings.NewReader(compressed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("can't decompress main.js%s: %v", ext, err)
+		}
+		if !bytes.Equal(data, []byte(main)) {
+			t.Errorf("main.js%s doesn't decompress to main.js", ext)
+		}
+	}
+}
+
+func TestCompressSkipsLargerVariants(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info(1);`,
+	})
+	cfg.Compress = "gzip"
+
+	mustBuild(t, cfg)
+
+	_, err := os.Stat(filepath.Join(cfg.OutDir, "main.js.gz"))
+	if !os.IsNotExist(err) {
+		t.Errorf("main.js.gz is written though it's larger: %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Options describes a build. Each option mirrors a minify_js flag, and can
+// be read from a JSON file with ReadOptions. The granular Minify* options are
+// nil unless set, otherwise they take precedence over Minify.
+type Options struct {
+	InDir              string            `json:"inDir"`
+	OutDir             string            `json:"outDir"`
+	ResolveMode        string            `json:"resolveMode"`
+	ImportMapPaths     pathList          `json:"importmapPath"`
+	StrictImportMap    bool              `json:"strictImportmap"`
+	VerifyImportMap    bool              `json:"verifyImportmap"`
+	NodePaths          []string          `json:"nodePaths"`
+	EntryPoints        []string          `json:"entryPoints"`
+	EntryRelative      string            `json:"entryRelative"`
+	Target             string            `json:"target"`
+	Format             string            `json:"format"`
+	GlobalName         string            `json:"globalName"`
+	DualFormat         bool              `json:"dualFormat"`
+	TransformOnly      bool              `json:"transform"`
+	Platform           string            `json:"platform"`
+	Sourcemap          string            `json:"sourcemap"`
+	NoSourcemapComment bool              `json:"noSourcemapComment"`
+	SourcesRoot        string            `json:"sourcesRoot"`
+	MapDir             string            `json:"writeMapDir"`
+	KeepNames          bool              `json:"keepNames"`
+	Minify             bool              `json:"minify"`
+	NoMinifyGlobs      []string          `json:"noMinifyGlob"`
+	MinifyWhitespace   *bool             `json:"minifyWhitespace"`
+	MinifySyntax       *bool             `json:"minifySyntax"`
+	MinifyIdentifiers  *bool             `json:"minifyIdentifiers"`
+	EntryNames         string            `json:"entryNames"`
+	ManifestPath       string            `json:"writeManifest"`
+	ManifestSchema     string            `json:"manifestSchema"`
+	Splitting          bool              `json:"splitting"`
+	ChunkNames         string            `json:"chunkNames"`
+	ChunkDir           string            `json:"chunkDir"`
+	AssetNames         string            `json:"assetNames"`
+	OutExtensions      map[string]string `json:"outExtension"`
+	PublicPath         string            `json:"publicPath"`
+	Metafile           string            `json:"metafile"`
+	GraphOut           string            `json:"graphOut"`
+	Integrity          bool              `json:"integrity"`
+	Loaders            map[string]string `json:"loaders"`
+	DefaultLoader      string            `json:"loaderDefault"`
+	FileLoaders        map[string]string `json:"fileLoaders"`
+	Defines            map[string]string `json:"define"`
+	FeaturesOff        []string          `json:"featureOff"`
+	ResolveExtensions  string            `json:"resolveExtensions"`
+	Conditions         string            `json:"conditions"`
+	MainFields         string            `json:"mainFields"`
+	Tsconfig           string            `json:"tsconfig"`
+	DefineEnv          string            `json:"defineEnv"`
+	DefineEnvStrict    bool              `json:"defineEnvStrict"`
+	Externals          []string          `json:"external"`
+	Aliases            map[string]string `json:"alias"`
+	Injects            []string          `json:"inject"`
+	LegalComments      string            `json:"legalComments"`
+	PureFunctions      []string          `json:"pure"`
+	MangleProps        string            `json:"mangleProps"`
+	ReserveProps       string            `json:"reserveProps"`
+	KeepConsole        bool              `json:"keepConsole"`
+	Drop               string            `json:"drop"`
+	PreserveSymlinks   bool              `json:"preserveSymlinks"`
+	TreeShaking        bool              `json:"treeShaking"`
+	IgnoreAnnotations  bool              `json:"ignoreAnnotations"`
+	ReportUnused       bool              `json:"reportUnused"`
+	DetectCycles       bool              `json:"detectCycles"`
+	CyclesFatal        bool              `json:"cyclesFatal"`
+	DetectDuplicates   bool              `json:"detectDuplicates"`
+	DuplicatesFatal    bool              `json:"duplicatesFatal"`
+	Summary            bool              `json:"summary"`
+	JSX                string            `json:"jsx"`
+	JSXFactory         string            `json:"jsxFactory"`
+	JSXFragment        string            `json:"jsxFragment"`
+	Charset            string            `json:"charset"`
+	Banner             string            `json:"banner"`
+	Footer             string            `json:"footer"`
+	MaxSize            string            `json:"maxSize"`
+	Compress           string            `json:"compress"`
+	PostBuild          string            `json:"postBuild"`
+	CacheDir           string            `json:"cacheDir"`
+	Timing             bool              `json:"timing"`
+	WarningsAsErrors   bool              `json:"warningsAsErrors"`
+	Color              string            `json:"color"`
+	Quiet              bool              `json:"quiet"`
+	Verbose            bool              `json:"verbose"`
+	LogFormat          string            `json:"logFormat"`
+	LogLimit           int               `json:"logLimit"`
+	Archive            string            `json:"archive"`
+	DryRun             bool              `json:"dryRun"`
+	Stdin              bool              `json:"stdin"`
+	StdinLoader        string            `json:"stdinLoader"`
+	StdinResolveDir    string            `json:"stdinResolveDir"`
+}
+
+// pathList is a list of paths that may also be given as a single string in
+// the config file.
+type pathList []string
+
+func (l *pathList) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		*l = pathList{path}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+// DefaultOptions returns the options minify_js starts from.
+func DefaultOptions() Options {
+	return Options{
+		ResolveMode: ResolveModeImportMap,
+		Format:      "esm",
+		Platform:    "browser",
+		Sourcemap:   "linked",
+		Charset:     "ascii",
+		LogFormat:   LogFormatText,
+		Color:       "auto",
+		LogLimit:    10,
+		StdinLoader: "js",
+		KeepNames:   true,
+		Minify:      true,
+		TreeShaking: true,
+		Splitting:   true,
+		// the shape the server looks hashed file names up in
+		ManifestSchema: ManifestSchemaFlat,
+		// the import map is meant to list every dependency of the UI
+		StrictImportMap: true,
+		// the layout of the ns_server UI tree
+		EntryRelative: "ui/app/main.js",
+		// modules linked into the source tree resolve their imports, and
+		// match import map scopes, relative to where they are linked
+		PreserveSymlinks: true,
+		Loaders: map[string]string{
+			".html": "text",
+			".jsx":  "jsx",
+			".ts":   "ts",
+			".tsx":  "tsx",
+		},
+	}
+}
+
+// ReadOptions reads the config at path on top of the default options.
+func ReadOptions(path string) (Options, error) {
+	cfg := DefaultOptions()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("cannot read config at %s: %s",
+			path, err.Error())
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("cannot parse config at %s: %s",
+			path, err.Error())
+	}
+
+	return cfg, nil
+}
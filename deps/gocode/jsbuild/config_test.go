@@ -0,0 +1,85 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes cfg as a JSON config file and returns its path.
+func writeConfig(t *testing.T, cfg any) string {
+	t.Helper()
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadOptionsRoundTrip(t *testing.T) {
+	minifySyntax := false
+	cfg := DefaultOptions()
+	cfg.InDir = "priv/public"
+	cfg.OutDir = "priv/public/out"
+	cfg.ImportMapPaths = pathList{"ui/importmap.json", "ui/extra.json"}
+	cfg.EntryPoints = []string{"ui/app/main.js", "login=ui/login.js"}
+	cfg.Target = "chrome100,firefox100"
+	cfg.Format = "iife"
+	cfg.Loaders[".svg"] = "text"
+	cfg.Defines = map[string]string{"DEBUG": "false"}
+	cfg.Externals = []string{"lib"}
+	cfg.MinifySyntax = &minifySyntax
+
+	read, err := ReadOptions(writeConfig(t, cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(read, cfg) {
+		t.Errorf("the config doesn't round trip:\n%+v\nexpected\n%+v",
+			read, cfg)
+	}
+}
+
+func TestReadOptionsDefaults(t *testing.T) {
+	read, err := ReadOptions(writeConfig(t, map[string]any{
+		"outDir":        "out",
+		"importmapPath": "ui/importmap.json",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := DefaultOptions()
+	expected.OutDir = "out"
+	expected.ImportMapPaths = pathList{"ui/importmap.json"}
+	if !reflect.DeepEqual(read, expected) {
+		t.Errorf("unexpected options:\n%+v\nexpected\n%+v", read, expected)
+	}
+}
+
+func TestReadOptionsUnknownField(t *testing.T) {
+	_, err := ReadOptions(writeConfig(t, map[string]any{
+		"outDirectory": "out",
+	}))
+
+	if err == nil ||
+		!strings.Contains(err.Error(), `unknown field "outDirectory"`) {
+		t.Errorf("expected an unknown field error, got %v", err)
+	}
+}
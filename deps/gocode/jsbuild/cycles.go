@@ -0,0 +1,121 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// findImportCycles returns the import cycles in the module graph described
+// by meta, every one as the chain of paths from a module back to itself.
+// Dynamic imports are left out, as they don't affect the order modules are
+// initialized in. Cycles that overlap one found already may not be returned,
+// fixing those found makes the next run show them.
+func findImportCycles(meta metafile) [][]string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var stack []string
+	var cycles [][]string
+
+	var visit func(path string)
+	visit = func(path string) {
+		state[path] = visiting
+		stack = append(stack, path)
+		for _, imp := range meta.Inputs[path].Imports {
+			if imp.External || imp.Kind == "dynamic-import" {
+				continue
+			}
+			if _, ok := meta.Inputs[imp.Path]; !ok {
+				continue
+			}
+			switch state[imp.Path] {
+			case unvisited:
+				visit(imp.Path)
+			case visiting:
+				start := len(stack) - 1
+				for stack[start] != imp.Path {
+					start--
+				}
+				cycle := append([]string(nil), stack[start:]...)
+				cycles = append(cycles, append(cycle, imp.Path))
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[path] = visited
+	}
+
+	paths := make([]string, 0, len(meta.Inputs))
+	for path := range meta.Inputs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if state[path] == unvisited {
+			visit(path)
+		}
+	}
+	return cycles
+}
+
+// getCyclesPlugin reports the import cycles of every successful build, with
+// paths relative to inDir. They are logged, unless fatal, in which case they
+// fail the build. It has to come before the plugins that skip failed builds.
+func getCyclesPlugin(inDir string, fatal bool) api.Plugin {
+	return api.Plugin{
+		Name: "Cycles",
+		Setup: func(build api.PluginBuild) {
+			workingDir := getWorkingDir(build.InitialOptions)
+
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				if len(result.Errors) > 0 {
+					return api.OnEndResult{}, nil
+				}
+				meta, err := parseMetafile(result.Metafile)
+				if err != nil {
+					return api.OnEndResult{}, err
+				}
+
+				var chains []string
+				for _, cycle := range findImportCycles(meta) {
+					for i, path := range cycle {
+						cycle[i] = relMetafilePath(workingDir, path, inDir)
+					}
+					chains = append(chains, strings.Join(cycle, " -> "))
+				}
+				if len(chains) == 0 {
+					return api.OnEndResult{}, nil
+				}
+
+				if fatal {
+					var onEnd api.OnEndResult
+					for _, chain := range chains {
+						onEnd.Errors = append(onEnd.Errors, api.Message{
+							Text: fmt.Sprintf("import cycle: %s", chain),
+						})
+					}
+					return onEnd, nil
+				}
+				log.Printf("Import cycles:\n")
+				for _, chain := range chains {
+					log.Printf("  %s\n", chain)
+				}
+				return api.OnEndResult{}, nil
+			})
+		},
+	}
+}
@@ -0,0 +1,61 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"strings"
+	"testing"
+)
+
+// cycleTree has main.js and lib.js import each other.
+var cycleTree = map[string]string{
+	"ui/app/lib.js": `import { main } from "./main.js";
+		export const lib = () => main;`,
+	"ui/app/main.js": `import { lib } from "./lib.js";
+		export const main = "main";
+		console.info(lib());`,
+}
+
+const cycleChain = "ui/app/lib.js -> ui/app/main.js -> ui/app/lib.js"
+
+func TestDetectCycles(t *testing.T) {
+	cfg := newTestTree(t, cycleTree)
+	cfg.DetectCycles = true
+	logged := captureLog(t)
+
+	mustBuild(t, cfg)
+
+	if !strings.Contains(logged.String(), "Import cycles:\n  "+cycleChain) {
+		t.Errorf("the cycle is not reported:\n%s", logged)
+	}
+}
+
+func TestCyclesFatal(t *testing.T) {
+	cfg := newTestTree(t, cycleTree)
+	cfg.DetectCycles = true
+	cfg.CyclesFatal = true
+
+	result := mustRun(t, cfg)
+
+	if !hasMessage(result.Errors, "import cycle: "+cycleChain) {
+		t.Errorf("expected the cycle to fail the build, got errors %v",
+			messageTexts(result.Errors))
+	}
+}
+
+func TestNoCycles(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/lib.js":  `export const lib = "lib";`,
+		"ui/app/main.js": `import { lib } from "./lib.js"; console.info(lib);`,
+	})
+	cfg.DetectCycles = true
+	cfg.CyclesFatal = true
+
+	mustBuild(t, cfg)
+}
@@ -0,0 +1,80 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/couchbase/ns_server/deps/gocode/esbuildutils"
+)
+
+// Subdirectories of -out-dir the -dual-format builds go to.
+const (
+	DualFormatESMDir    = "esm"
+	DualFormatLegacyDir = "legacy"
+)
+
+// runDualFormat builds cfg twice, as esm into <out-dir>/esm and as iife
+// into <out-dir>/legacy, and merges the results of the two builds.
+func runDualFormat(cfg Options) (Result, error) {
+	switch {
+	case cfg.OutDir == "":
+		return Result{}, &OptionsError{errors.New("-dual-format needs " +
+			"-out-dir")}
+	case cfg.Metafile != "" || cfg.GraphOut != "" || cfg.Archive != "" ||
+		cfg.ManifestPath != "":
+		return Result{}, &OptionsError{errors.New("-dual-format can't be " +
+			"used with -metafile, -graph-out, -write-manifest or -archive, " +
+			"the two builds would overwrite each other's")}
+	}
+	cfg.DualFormat = false
+
+	esm := cfg
+	esm.Format = "esm"
+	esm.OutDir = filepath.Join(cfg.OutDir, DualFormatESMDir)
+	// the global name is only for the legacy build
+	esm.GlobalName = ""
+
+	legacy := cfg
+	legacy.Format = "iife"
+	legacy.OutDir = filepath.Join(cfg.OutDir, DualFormatLegacyDir)
+	// iife can't be split, this just saves the warning
+	legacy.Splitting = false
+
+	// both builds are checked before any dir is created
+	for _, build := range []Options{esm, legacy} {
+		if err := makePathsAbsolute(&build); err != nil {
+			return Result{}, &OptionsError{err}
+		}
+		if _, err := buildOptions(build); err != nil {
+			return Result{}, &OptionsError{err}
+		}
+	}
+	if !cfg.DryRun {
+		// the two builds create their own dirs in it
+		if err := esbuildutils.PrepareOutputDir(cfg.OutDir); err != nil {
+			return Result{}, &OptionsError{err}
+		}
+	}
+
+	var merged Result
+	for _, build := range []Options{esm, legacy} {
+		result, err := Run(build)
+		if err != nil {
+			return Result{}, err
+		}
+		merged.Errors = append(merged.Errors, result.Errors...)
+		merged.Warnings = append(merged.Warnings, result.Warnings...)
+		merged.OutputFiles = append(merged.OutputFiles,
+			result.OutputFiles...)
+		merged.OverBudget = merged.OverBudget || result.OverBudget
+	}
+	return merged, nil
+}
@@ -0,0 +1,58 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"strings"
+	"testing"
+)
+
+const dualFormatSource = `export const answer = 42;
+	console.info(answer);`
+
+// checkDualFormat checks that the esm and legacy builds of
+// dualFormatSource are in their subdirs of outDir, with their module
+// shapes.
+func checkDualFormat(t *testing.T, result Result, outDir, name string) {
+	t.Helper()
+	esm := output(t, result, outDir, DualFormatESMDir+"/"+name)
+	if !strings.Contains(esm, "export{") || !strings.Contains(esm, "42") {
+		t.Errorf("the esm build doesn't export answer:\n%s", esm)
+	}
+	legacy := output(t, result, outDir, DualFormatLegacyDir+"/"+name)
+	if !strings.HasPrefix(legacy, "var app=(()=>{") ||
+		!strings.Contains(legacy, "42") {
+		t.Errorf("the legacy build isn't an iife assigned to app:\n%s",
+			legacy)
+	}
+}
+
+func TestDualFormat(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": dualFormatSource,
+	})
+	cfg.DualFormat = true
+	cfg.GlobalName = "app"
+
+	result := mustBuild(t, cfg)
+
+	checkDualFormat(t, result, cfg.OutDir, "main.js")
+}
+
+func TestDualFormatStdin(t *testing.T) {
+	cfg := newTestTree(t, nil)
+	cfg.DualFormat = true
+	cfg.GlobalName = "app"
+	cfg.Stdin = true
+	setStdin(t, dualFormatSource)
+
+	result := mustBuild(t, cfg)
+
+	checkDualFormat(t, result, cfg.OutDir, "stdin.js")
+}
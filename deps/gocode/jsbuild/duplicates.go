@@ -0,0 +1,176 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+type packageInfo struct {
+	dir     string
+	name    string
+	version string
+}
+
+// packageFinder finds the package a module belongs to, caching what it
+// learns about every dir.
+type packageFinder map[string]*packageInfo
+
+// find returns the package owning the file at path: the one described by
+// the nearest package.json with a name, if any.
+func (f packageFinder) find(path string) *packageInfo {
+	return f.findDir(filepath.Dir(path))
+}
+
+func (f packageFinder) findDir(dir string) *packageInfo {
+	if pkg, ok := f[dir]; ok {
+		return pkg
+	}
+
+	var pkg *packageInfo
+	var manifest struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err == nil && json.Unmarshal(data, &manifest) == nil &&
+		manifest.Name != "" {
+		pkg = &packageInfo{dir, manifest.Name, manifest.Version}
+	} else if parent := filepath.Dir(dir); parent != dir {
+		pkg = f.findDir(parent)
+	}
+	f[dir] = pkg
+	return pkg
+}
+
+// findDuplicates returns the modules of the build described by meta that
+// are bundled more than once: the packages bundled from more than one dir,
+// and the files with the same contents bundled under different paths.
+// Paths are relative to inDir.
+func findDuplicates(meta metafile, workingDir, inDir string) []string {
+	rel := func(path string) string {
+		return relMetafilePath(workingDir, path, inDir)
+	}
+
+	packages := make(packageFinder)
+	pkgDirs := make(map[string]map[string]*packageInfo)
+	byHash := make(map[[sha256.Size]byte][]string)
+	for path, input := range meta.Inputs {
+		// skip inputs from other namespaces, like "<stdin>"
+		if strings.Contains(path, ":") || strings.HasPrefix(path, "<") ||
+			input.Bytes == 0 {
+			continue
+		}
+		abs := filepath.Join(workingDir, path)
+		if pkg := packages.find(abs); pkg != nil {
+			if pkgDirs[pkg.name] == nil {
+				pkgDirs[pkg.name] = make(map[string]*packageInfo)
+			}
+			pkgDirs[pkg.name][pkg.dir] = pkg
+		}
+		if contents, err := os.ReadFile(abs); err == nil {
+			hash := sha256.Sum256(contents)
+			byHash[hash] = append(byHash[hash], path)
+		}
+	}
+
+	var duplicates []string
+	duplicated := make(map[string]bool)
+	for name, dirs := range pkgDirs {
+		if len(dirs) < 2 {
+			continue
+		}
+		var copies []string
+		for dir, pkg := range dirs {
+			duplicated[dir] = true
+			// metafile paths are relative to the working dir
+			if r, err := filepath.Rel(workingDir, dir); err == nil {
+				dir = r
+			}
+			copies = append(copies, fmt.Sprintf("%s (%s)", rel(dir),
+				pkg.version))
+		}
+		sort.Strings(copies)
+		duplicates = append(duplicates, fmt.Sprintf("package %s from %s",
+			name, strings.Join(copies, ", ")))
+	}
+	for _, paths := range byHash {
+		if len(paths) < 2 {
+			continue
+		}
+		var copies []string
+		for _, path := range paths {
+			// already reported with their package
+			pkg := packages.find(filepath.Join(workingDir, path))
+			if pkg == nil || !duplicated[pkg.dir] {
+				copies = append(copies, rel(path))
+			}
+		}
+		if len(copies) < 2 {
+			continue
+		}
+		sort.Strings(copies)
+		duplicates = append(duplicates, fmt.Sprintf("module %s",
+			strings.Join(copies, " = ")))
+	}
+	sort.Strings(duplicates)
+	return duplicates
+}
+
+// getDuplicatesPlugin reports the modules bundled more than once by every
+// successful build. They are logged, unless fatal, in which case they fail
+// the build. It has to come before the plugins that skip failed builds.
+func getDuplicatesPlugin(inDir string, fatal bool) api.Plugin {
+	return api.Plugin{
+		Name: "Duplicates",
+		Setup: func(build api.PluginBuild) {
+			workingDir := getWorkingDir(build.InitialOptions)
+
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				if len(result.Errors) > 0 {
+					return api.OnEndResult{}, nil
+				}
+				meta, err := parseMetafile(result.Metafile)
+				if err != nil {
+					return api.OnEndResult{}, err
+				}
+
+				duplicates := findDuplicates(meta, workingDir, inDir)
+				if len(duplicates) == 0 {
+					return api.OnEndResult{}, nil
+				}
+
+				if fatal {
+					var onEnd api.OnEndResult
+					for _, duplicate := range duplicates {
+						onEnd.Errors = append(onEnd.Errors, api.Message{
+							Text: fmt.Sprintf("bundled more than once: %s",
+								duplicate),
+						})
+					}
+					return onEnd, nil
+				}
+				log.Printf("Bundled more than once:\n")
+				for _, duplicate := range duplicates {
+					log.Printf("  %s\n", duplicate)
+				}
+				return api.OnEndResult{}, nil
+			})
+		},
+	}
+}
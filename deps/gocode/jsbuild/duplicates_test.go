@@ -0,0 +1,76 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectDuplicateModule(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/web_modules/lib.js": `export const lib = {name: "lib"};`,
+		"ui/app/main.js": `import { lib as a } from "../web_modules/lib.js";
+			import { lib as b } from "../vendor/lib.js";
+			console.info(a === b);`,
+	})
+	// with the symlink preserved, lib.js is reachable by two paths
+	err := os.Symlink(filepath.Join(cfg.InDir, "ui", "web_modules"),
+		filepath.Join(cfg.InDir, "ui", "vendor"))
+	if err != nil {
+		t.Skipf("can't create the symlink: %v", err)
+	}
+	cfg.PreserveSymlinks = true
+	cfg.DetectDuplicates = true
+	logged := captureLog(t)
+
+	mustBuild(t, cfg)
+
+	expected := "Bundled more than once:\n" +
+		"  module ui/vendor/lib.js = ui/web_modules/lib.js\n"
+	if !strings.Contains(logged.String(), expected) {
+		t.Errorf("the duplicate isn't reported:\n%s", logged)
+	}
+}
+
+func TestDuplicatesFatal(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/web_modules/lib/package.json": `{"name": "lib",
+			"version": "1.0.0"}`,
+		"ui/web_modules/lib/index.js": `export const a = "lib 1";`,
+		"ui/app/node_modules/lib/package.json": `{"name": "lib",
+			"version": "2.0.0"}`,
+		"ui/app/node_modules/lib/index.js": `export const b = "lib 2";`,
+		"ui/app/main.js": `import { a } from "../web_modules/lib/index.js";
+			import { b } from "./node_modules/lib/index.js";
+			console.info(a, b);`,
+	})
+	cfg.DetectDuplicates = true
+	cfg.DuplicatesFatal = true
+
+	result := mustRun(t, cfg)
+
+	expected := "bundled more than once: package lib from " +
+		"ui/app/node_modules/lib (2.0.0), ui/web_modules/lib (1.0.0)"
+	if !hasMessage(result.Errors, expected) {
+		t.Errorf("expected the duplicate package to fail the build, got "+
+			"errors %v", messageTexts(result.Errors))
+	}
+}
+
+func TestNoDuplicates(t *testing.T) {
+	cfg := newTestTree(t, sharedCodeTree)
+	cfg.EntryPoints = []string{"ui/app/main.js", "ui/app/admin.js"}
+	cfg.DetectDuplicates = true
+	cfg.DuplicatesFatal = true
+
+	mustBuild(t, cfg)
+}
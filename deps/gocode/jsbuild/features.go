@@ -0,0 +1,94 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// featureRe matches the comment tagging a module as part of a feature,
+// e.g. "// @feature analytics".
+var featureRe = regexp.MustCompile(`^//\s*@feature\s+(\S+)\s*$`)
+
+// sourceFileFilter matches the files that may carry a @feature tag.
+const sourceFileFilter = `\.([cm]?js|jsx|ts|tsx)$`
+
+// readFeatures returns the features the module at path is tagged with in
+// the line comments it starts with.
+func readFeatures(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var features []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "//") {
+			break
+		}
+		if m := featureRe.FindStringSubmatch(line); m != nil {
+			features = append(features, m[1])
+		}
+	}
+	return features, scanner.Err()
+}
+
+// checkFeatures validates the -feature-off names.
+func checkFeatures(features []string) error {
+	for _, feature := range features {
+		if feature == "" || strings.ContainsFunc(feature, unicode.IsSpace) {
+			return fmt.Errorf("invalid feature name '%s'", feature)
+		}
+	}
+	return nil
+}
+
+// getFeaturesPlugin loads the modules tagged with one of the features that
+// are off as empty ones, so that none of their code, nor that of the
+// modules only they import, ends up in the output. Imports of their
+// exports are then undefined, which esbuild warns about.
+func getFeaturesPlugin(off []string) api.Plugin {
+	return api.Plugin{
+		Name: "Features",
+		Setup: func(build api.PluginBuild) {
+			build.OnLoad(api.OnLoadOptions{Filter: sourceFileFilter,
+				Namespace: "file"},
+				func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+					features, err := readFeatures(args.Path)
+					if err != nil {
+						return api.OnLoadResult{}, err
+					}
+					for _, feature := range features {
+						if slices.Contains(off, feature) {
+							empty := ""
+							return api.OnLoadResult{
+								Contents: &empty,
+								Loader:   api.LoaderJS,
+							}, nil
+						}
+					}
+					return api.OnLoadResult{}, nil
+				})
+		},
+	}
+}
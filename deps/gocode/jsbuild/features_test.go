@@ -0,0 +1,78 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"strings"
+	"testing"
+)
+
+// featureTree has main.js import a module tagged with the analytics
+// feature, which in turn imports a module of its own.
+var featureTree = map[string]string{
+	"ui/app/tracker.js": `export function track() {
+		console.info("the tracker");
+	}`,
+	"ui/app/analytics.js": `// @author Couchbase <info@couchbase.com>
+		// @feature analytics
+		import { track } from "./tracker.js";
+		console.info("the analytics");
+		track();`,
+	"ui/app/main.js": `import "./analytics.js";
+		console.info("main");`,
+}
+
+func TestFeatureOff(t *testing.T) {
+	cfg := newTestTree(t, featureTree)
+	cfg.FeaturesOff = []string{"analytics"}
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, `"main"`) {
+		t.Errorf("the rest of the code is missing:\n%s", main)
+	}
+	for _, text := range []string{"the analytics", "the tracker"} {
+		if strings.Contains(main, text) {
+			t.Errorf("the code of the feature that's off is bundled:\n%s",
+				main)
+		}
+	}
+}
+
+func TestFeatureOn(t *testing.T) {
+	cfg := newTestTree(t, featureTree)
+	cfg.FeaturesOff = []string{"reports"}
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	for _, text := range []string{"the analytics", "the tracker"} {
+		if !strings.Contains(main, text) {
+			t.Errorf("%q of a feature that's on is missing:\n%s", text,
+				main)
+		}
+	}
+}
+
+func TestCheckFeatures(t *testing.T) {
+	for _, test := range []struct {
+		features []string
+		valid    bool
+	}{
+		{[]string{"analytics", "reports"}, true},
+		{[]string{""}, false},
+		{[]string{"two words"}, false},
+	} {
+		if err := checkFeatures(test.features); (err == nil) != test.valid {
+			t.Errorf("checkFeatures(%q): unexpected error %v",
+				test.features, err)
+		}
+	}
+}
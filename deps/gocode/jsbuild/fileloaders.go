@@ -0,0 +1,120 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+type fileLoader struct {
+	pattern string
+	loader  api.Loader
+}
+
+// newFileLoaders validates the -file-loader patterns and orders them from
+// the most specific (longest) pattern to the least specific one, which is
+// the order they are tried in.
+func newFileLoaders(loaders map[string]api.Loader) ([]fileLoader, error) {
+	var sorted []fileLoader
+	for pattern, loader := range loaders {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid file loader pattern '%s': %s",
+				pattern, err.Error())
+		}
+		sorted = append(sorted, fileLoader{pattern, loader})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i].pattern) != len(sorted[j].pattern) {
+			return len(sorted[i].pattern) > len(sorted[j].pattern)
+		}
+		return sorted[i].pattern < sorted[j].pattern
+	})
+	return sorted, nil
+}
+
+// getFileLoaderPlugin loads the files matching one of the -file-loader
+// patterns, which are matched against the path relative to inDir, with the
+// loader of the first matching pattern. This takes precedence over the
+// per-extension loaders, and applies to modules reached through the import
+// map just as well, since those are plain files too.
+func getFileLoaderPlugin(inDir string, loaders []fileLoader) api.Plugin {
+	return api.Plugin{
+		Name: "FileLoader",
+		Setup: func(build api.PluginBuild) {
+			build.OnLoad(api.OnLoadOptions{Filter: `.*`, Namespace: "file"},
+				func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+					rel, err := filepath.Rel(inDir, args.Path)
+					if err != nil {
+						return api.OnLoadResult{}, nil
+					}
+					rel = filepath.ToSlash(rel)
+
+					for _, l := range loaders {
+						if ok, _ := path.Match(l.pattern, rel); !ok {
+							continue
+						}
+						contents, err := os.ReadFile(args.Path)
+						if err != nil {
+							return api.OnLoadResult{}, err
+						}
+						text := string(contents)
+						return api.OnLoadResult{
+							Contents:   &text,
+							Loader:     l.loader,
+							ResolveDir: filepath.Dir(args.Path),
+						}, nil
+					}
+					return api.OnLoadResult{}, nil
+				})
+		},
+	}
+}
+
+// esbuildLoaderExts are the extensions esbuild has a loader for by default.
+var esbuildLoaderExts = []string{
+	".cjs", ".css", ".cts", ".js", ".json", ".jsx", ".mjs", ".mts", ".ts",
+	".tsx", ".txt",
+}
+
+// getDefaultLoaderPlugin loads the files whose extension has no loader,
+// neither one of esbuild's nor one in loaders, with defaultLoader instead
+// of failing the build.
+func getDefaultLoaderPlugin(defaultLoader api.Loader,
+	loaders map[string]api.Loader) api.Plugin {
+	return api.Plugin{
+		Name: "DefaultLoader",
+		Setup: func(build api.PluginBuild) {
+			build.OnLoad(api.OnLoadOptions{Filter: `.*`, Namespace: "file"},
+				func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+					ext := filepath.Ext(args.Path)
+					if _, ok := loaders[ext]; ok ||
+						slices.Contains(esbuildLoaderExts, ext) {
+						return api.OnLoadResult{}, nil
+					}
+					contents, err := os.ReadFile(args.Path)
+					if err != nil {
+						return api.OnLoadResult{}, err
+					}
+					text := string(contents)
+					return api.OnLoadResult{
+						Contents:   &text,
+						Loader:     defaultLoader,
+						ResolveDir: filepath.Dir(args.Path),
+					}, nil
+				})
+		},
+	}
+}
@@ -0,0 +1,90 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// a global variable, or a property of one, e.g. couchbase.ui
+var globalNameRe = regexp.MustCompile(
+	`^[A-Za-z_$][\w$]*(\.[A-Za-z_$][\w$]*)*$`)
+
+// getEntryPointsWithExports returns the entry points of opts that export
+// anything. Only the entry points themselves are parsed, their imports are
+// not followed.
+func getEntryPointsWithExports(opts api.BuildOptions) ([]string, error) {
+	result := api.Build(api.BuildOptions{
+		EntryPointsAdvanced: opts.EntryPointsAdvanced,
+		AbsWorkingDir:       opts.AbsWorkingDir,
+		Outdir:              "out",
+		Format:              api.FormatESModule,
+		Loader:              opts.Loader,
+		Tsconfig:            opts.Tsconfig,
+		Metafile:            true,
+		LogLevel:            api.LogLevelSilent,
+	})
+	if len(result.Errors) > 0 {
+		// the build proper reports them
+		return nil, nil
+	}
+
+	meta, err := parseMetafile(result.Metafile)
+	if err != nil {
+		return nil, err
+	}
+	var entries []string
+	for _, output := range meta.Outputs {
+		if output.EntryPoint != "" && len(output.Exports) > 0 {
+			entries = append(entries, output.EntryPoint)
+		}
+	}
+	sort.Strings(entries)
+	return entries, nil
+}
+
+// checkGlobalName checks the -global-name of an iife build with opts, which
+// its entry points' exports are assigned to. Without it they would not be
+// reachable, so it is required if any of the entry points has exports.
+func checkGlobalName(name string, opts api.BuildOptions) error {
+	if opts.Format != api.FormatIIFE {
+		if name != "" {
+			return errors.New("-global-name can only be used with " +
+				"-format=iife")
+		}
+		return nil
+	}
+
+	if name != "" {
+		if !globalNameRe.MatchString(name) {
+			return fmt.Errorf("invalid -global-name '%s', expected an "+
+				"identifier or a dotted path like couchbase.ui", name)
+		}
+		return nil
+	}
+
+	if opts.Stdin != nil {
+		return nil
+	}
+	entries, err := getEntryPointsWithExports(opts)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("-global-name is needed to expose the exports "+
+			"of %s in an iife build", strings.Join(entries, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGlobalName(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `export const version = "7.6.0";`,
+	})
+	cfg.Format = "iife"
+	cfg.GlobalName = "couchbase.ui"
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, "var couchbase=couchbase||{};couchbase.ui=") {
+		t.Errorf("the exports are not assigned to couchbase.ui:\n%s", main)
+	}
+
+	for _, test := range []struct {
+		format     string
+		globalName string
+		expected   string
+	}{
+		{"iife", "", "-global-name is needed to expose the exports of " +
+			"ui/app/main.js in an iife build"},
+		{"iife", "couchbase-ui", "invalid -global-name 'couchbase-ui', " +
+			"expected an identifier or a dotted path like couchbase.ui"},
+		{"esm", "couchbase", "-global-name can only be used with " +
+			"-format=iife"},
+	} {
+		cfg.Format = test.format
+		cfg.GlobalName = test.globalName
+
+		_, err := Run(cfg)
+
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%s %q: expected error %q, got %v", test.format,
+				test.globalName, test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// formatDependencyGraph renders the module graph described by meta in DOT
+// format, with paths relative to inDir. External imports are left out.
+func formatDependencyGraph(meta metafile, workingDir, inDir string) string {
+	rel := func(path string) string {
+		return strconv.Quote(relMetafilePath(workingDir, path, inDir))
+	}
+
+	var nodes, edges []string
+	for path, input := range meta.Inputs {
+		nodes = append(nodes, fmt.Sprintf("  %s;\n", rel(path)))
+		for _, imp := range input.Imports {
+			if imp.External {
+				continue
+			}
+			if _, ok := meta.Inputs[imp.Path]; !ok {
+				continue
+			}
+			edges = append(edges, fmt.Sprintf("  %s -> %s;\n",
+				rel(path), rel(imp.Path)))
+		}
+	}
+	sort.Strings(nodes)
+	sort.Strings(edges)
+
+	var b strings.Builder
+	b.WriteString("digraph modules {\n")
+	for _, node := range nodes {
+		b.WriteString(node)
+	}
+	for _, edge := range edges {
+		b.WriteString(edge)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// getGraphPlugin writes the module graph of every successful build to
+// graphPath as a Graphviz DOT file.
+func getGraphPlugin(inDir, graphPath string) api.Plugin {
+	return api.Plugin{
+		Name: "Graph",
+		Setup: func(build api.PluginBuild) {
+			workingDir := getWorkingDir(build.InitialOptions)
+
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				if len(result.Errors) > 0 {
+					return api.OnEndResult{}, nil
+				}
+				meta, err := parseMetafile(result.Metafile)
+				if err != nil {
+					return api.OnEndResult{}, err
+				}
+				graph := formatDependencyGraph(meta, workingDir, inDir)
+				err = os.WriteFile(graphPath, []byte(graph), 0644)
+				return api.OnEndResult{}, err
+			})
+		},
+	}
+}
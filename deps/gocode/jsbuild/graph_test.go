@@ -0,0 +1,35 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGraphOut(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/lib.js": `export const lib = "lib";`,
+		"ui/app/main.js": `import { lib } from "./lib.js";
+			console.info(lib);`,
+	})
+	cfg.GraphOut = filepath.Join(cfg.InDir, "modules.dot")
+
+	mustBuild(t, cfg)
+
+	expected := `digraph modules {
+  "ui/app/lib.js";
+  "ui/app/main.js";
+  "ui/app/main.js" -> "ui/app/lib.js";
+}
+`
+	if graph := readFile(t, cfg.GraphOut); graph != expected {
+		t.Errorf("unexpected graph:\n%s\nexpected:\n%s", graph, expected)
+	}
+}
@@ -0,0 +1,182 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// emptyImportMap is the import map of the test trees that don't have one.
+const emptyImportMap = `{"imports": {}}`
+
+// writeTree writes files, keyed by slash separated paths relative to dir.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newTestTree writes files to a temporary dir laid out like the ns_server
+// UI tree, with the entry point at ui/app/main.js and the import map at
+// ui/importmap.json, which is empty unless given in files. It returns the
+// default options to build the tree into its out dir, without source maps.
+func newTestTree(t *testing.T, files map[string]string) Options {
+	t.Helper()
+	dir := t.TempDir()
+	if _, ok := files["ui/importmap.json"]; !ok {
+		writeTree(t, dir, map[string]string{
+			"ui/importmap.json": emptyImportMap,
+		})
+	}
+	writeTree(t, dir, files)
+
+	cfg := DefaultOptions()
+	cfg.InDir = dir
+	cfg.OutDir = filepath.Join(dir, "out")
+	cfg.ImportMapPaths = pathList{filepath.Join(dir, "ui", "importmap.json")}
+	cfg.Sourcemap = "none"
+	return cfg
+}
+
+// mustRun runs the build of cfg and fails the test if it can't be run.
+func mustRun(t *testing.T, cfg Options) Result {
+	t.Helper()
+	result, err := Run(cfg)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return result
+}
+
+// mustBuild runs the build of cfg and fails the test unless it succeeds.
+func mustBuild(t *testing.T, cfg Options) Result {
+	t.Helper()
+	result := mustRun(t, cfg)
+	if len(result.Errors) > 0 {
+		t.Fatalf("build failed: %v", messageTexts(result.Errors))
+	}
+	return result
+}
+
+// output returns the contents of the output at the slash separated path
+// relative to outDir, failing the test if the build has no such output.
+func output(t *testing.T, result Result, outDir, name string) string {
+	t.Helper()
+	path := filepath.Join(outDir, filepath.FromSlash(name))
+	for _, file := range result.OutputFiles {
+		if file.Path == path {
+			return string(file.Contents)
+		}
+	}
+	var paths []string
+	for _, file := range result.OutputFiles {
+		paths = append(paths, file.Path)
+	}
+	t.Fatalf("no output %s in %v", path, paths)
+	return ""
+}
+
+// readFile returns the contents of the file at path, failing the test if
+// it can't be read.
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// setStdin makes the builds of the test read contents from stdin.
+func setStdin(t *testing.T, contents string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdin
+	os.Stdin = f
+	stdinInput.once = sync.Once{}
+	t.Cleanup(func() {
+		os.Stdin = orig
+		stdinInput.once = sync.Once{}
+		f.Close()
+	})
+}
+
+// syncBuffer is a bytes.Buffer that can be logged to from the goroutines
+// of Watch while the test reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// captureLog redirects the log output of the test to a buffer, without
+// the timestamps, as the tools log.
+func captureLog(t *testing.T) *syncBuffer {
+	t.Helper()
+	var buf syncBuffer
+	flags := log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+	})
+	return &buf
+}
+
+func messageTexts(messages []api.Message) []string {
+	var texts []string
+	for _, msg := range messages {
+		texts = append(texts, msg.Text)
+	}
+	return texts
+}
+
+// hasMessage reports whether the text of one of messages contains text.
+func hasMessage(messages []api.Message, text string) bool {
+	for _, msg := range messages {
+		if strings.Contains(msg.Text, text) {
+			return true
+		}
+	}
+	return false
+}
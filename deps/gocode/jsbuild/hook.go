@@ -0,0 +1,59 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// shellCommand returns the command that runs command in the shell.
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
+
+// runPostBuild runs command in the shell, with MINIFY_OUT_DIR set to
+// outDir.
+func runPostBuild(command, outDir string) error {
+	cmd := shellCommand(command)
+	cmd.Env = append(os.Environ(), "MINIFY_OUT_DIR="+outDir)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("post-build command failed: %s", err.Error())
+	}
+	return nil
+}
+
+// getPostBuildPlugin runs the -post-build command of cfg after every
+// successful build that fits in its -max-size. It has to come after the
+// plugins that write outputs, like the compress one. The build fails if the
+// command does.
+func getPostBuildPlugin(cfg Options) api.Plugin {
+	return api.Plugin{
+		Name: "PostBuild",
+		Setup: func(build api.PluginBuild) {
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				if len(result.Errors) > 0 ||
+					overSizeBudget(cfg, result.OutputFiles) {
+					return api.OnEndResult{}, nil
+				}
+				return api.OnEndResult{}, runPostBuild(cfg.PostBuild,
+					cfg.OutDir)
+			})
+		},
+	}
+}
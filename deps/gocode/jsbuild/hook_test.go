@@ -0,0 +1,149 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// newHookTestTree returns the options of a test tree built with a
+// -post-build command that appends MINIFY_OUT_DIR to the sentinel file it
+// also returns.
+func newHookTestTree(t *testing.T) (Options, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the post-build command is a sh one")
+	}
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("main");`,
+	})
+	sentinel := filepath.Join(cfg.InDir, "sentinel")
+	cfg.PostBuild = `echo "$MINIFY_OUT_DIR" >> ` + sentinel
+	return cfg, sentinel
+}
+
+func TestPostBuild(t *testing.T) {
+	cfg, sentinel := newHookTestTree(t)
+
+	mustBuild(t, cfg)
+
+	if runs := readFile(t, sentinel); runs != cfg.OutDir+"\n" {
+		t.Errorf("unexpected post-build runs:\n%s", runs)
+	}
+}
+
+func TestPostBuildFailure(t *testing.T) {
+	cfg, _ := newHookTestTree(t)
+	cfg.PostBuild = "exit 1"
+
+	result := mustRun(t, cfg)
+
+	if !hasMessage(result.Errors, "post-build command failed") {
+		t.Errorf("unexpected errors: %v", messageTexts(result.Errors))
+	}
+}
+
+func TestPostBuildCached(t *testing.T) {
+	cfg, sentinel := newHookTestTree(t)
+	cfg.CacheDir = filepath.Join(cfg.InDir, "cache")
+
+	mustBuild(t, cfg)
+	mustBuild(t, cfg)
+
+	if runs := strings.Count(readFile(t, sentinel), "\n"); runs != 2 {
+		t.Errorf("expected a post-build run per build, got %d", runs)
+	}
+}
+
+func TestPostBuildCachedFailure(t *testing.T) {
+	cfg, sentinel := newHookTestTree(t)
+	cfg.CacheDir = filepath.Join(cfg.InDir, "cache")
+	// fails once the sentinel exists
+	cfg.PostBuild = "test ! -e " + sentinel + " && touch " + sentinel
+
+	mustBuild(t, cfg)
+	result := mustRun(t, cfg)
+
+	if !hasMessage(result.Errors, "post-build command failed") {
+		t.Errorf("unexpected errors: %v", messageTexts(result.Errors))
+	}
+}
+
+func TestPostBuildAfterCompress(t *testing.T) {
+	cfg, sentinel := newHookTestTree(t)
+	main := `console.info("` + strings.Repeat("compressible ", 100) + `");`
+	writeTree(t, cfg.InDir, map[string]string{"ui/app/main.js": main})
+	cfg.Compress = "gzip"
+	cfg.PostBuild = `test -e "$MINIFY_OUT_DIR/main.js.gz" && touch ` + sentinel
+
+	result := mustRun(t, cfg)
+
+	if len(result.Errors) > 0 {
+		t.Fatalf("the post-build command doesn't see main.js.gz: %v",
+			messageTexts(result.Errors))
+	}
+	readFile(t, sentinel)
+}
+
+func TestPostBuildOverBudget(t *testing.T) {
+	cfg, sentinel := newHookTestTree(t)
+	cfg.MaxSize = "10"
+	captureLog(t)
+
+	result := mustRun(t, cfg)
+
+	if !result.OverBudget {
+		t.Fatal("the build isn't reported over budget")
+	}
+	if _, err := os.Stat(sentinel); !os.IsNotExist(err) {
+		t.Errorf("the post-build command runs over budget: %v", err)
+	}
+}
+
+func TestPostBuildOverBudgetCached(t *testing.T) {
+	cfg, sentinel := newHookTestTree(t)
+	cfg.CacheDir = filepath.Join(cfg.InDir, "cache")
+	cfg.MaxSize = "10"
+	captureLog(t)
+
+	mustRun(t, cfg)
+	result := mustRun(t, cfg)
+
+	if !result.OverBudget {
+		t.Fatal("the restored build isn't reported over budget")
+	}
+	if _, err := os.Stat(sentinel); !os.IsNotExist(err) {
+		t.Errorf("the post-build command runs over budget: %v", err)
+	}
+}
+
+func TestPostBuildOverBudgetWatch(t *testing.T) {
+	cfg, sentinel := newHookTestTree(t)
+	cfg.MaxSize = "100"
+	captureLog(t)
+
+	results := watchResults(t, cfg, WatchOptions{})
+	nextResult(t, results)
+	if err := os.Remove(sentinel); err != nil {
+		t.Fatalf("the post-build command doesn't run: %v", err)
+	}
+
+	large := `console.info("` + strings.Repeat("x", 200) + `");`
+	writeTree(t, cfg.InDir, map[string]string{"ui/app/main.js": large})
+	if !nextResult(t, results).OverBudget {
+		t.Fatal("the rebuild isn't reported over budget")
+	}
+	if _, err := os.Stat(sentinel); !os.IsNotExist(err) {
+		t.Errorf("the post-build command runs over budget: %v", err)
+	}
+}
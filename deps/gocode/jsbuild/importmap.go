@@ -0,0 +1,519 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2016-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// ImportMap is the parsed importmap.json. Integrity optionally maps files,
+// given like the mapped paths, to their expected "sha256-<base64>" hash.
+type ImportMap struct {
+	Imports   map[string]string            `json:"imports"`
+	Scopes    map[string]map[string]string `json:"scopes"`
+	Integrity map[string]string            `json:"integrity"`
+}
+
+type importMapScope struct {
+	prefix  string
+	imports map[string]string
+}
+
+type importMapResolver struct {
+	imports map[string]string
+	// sorted from the most specific (longest) prefix to the least specific
+	// one
+	scopes []importMapScope
+	// expected hashes by absolute file path
+	integrity map[string]string
+}
+
+// importMapPath converts a path in the import map to a file path. Paths are
+// relative to inDir/ui, where importmap.json is served from, except those
+// starting with '/', which are relative to inDir, the root of the served
+// tree, and absolute Windows paths, which are used as is.
+func importMapPath(inDir, path string) string {
+	switch {
+	case strings.HasPrefix(path, "/"):
+		return filepath.Join(inDir, path)
+	case filepath.IsAbs(path):
+		return path
+	}
+	return filepath.Join(inDir, "ui", path)
+}
+
+func newImportMapResolver(importmap ImportMap, inDir string) *importMapResolver {
+	r := &importMapResolver{imports: importmap.Imports}
+
+	for key, imports := range importmap.Scopes {
+		prefix := importMapPath(inDir, key)
+		if strings.HasSuffix(key, "/") {
+			prefix += string(filepath.Separator)
+		}
+		r.scopes = append(r.scopes, importMapScope{prefix, imports})
+	}
+
+	sort.Slice(r.scopes, func(i, j int) bool {
+		return len(r.scopes[i].prefix) > len(r.scopes[j].prefix)
+	})
+
+	r.integrity = make(map[string]string)
+	for key, hash := range importmap.Integrity {
+		r.integrity[importMapPath(inDir, key)] = hash
+	}
+
+	return r
+}
+
+// lookupSpecifier looks specifier up in imports. An exact match wins,
+// otherwise the longest key ending in '/' that prefixes specifier is used
+// and the rest of specifier is appended to its value.
+func lookupSpecifier(imports map[string]string, specifier string) (string, bool) {
+	if mapped, ok := imports[specifier]; ok {
+		return mapped, true
+	}
+
+	var prefix string
+	for key, mapped := range imports {
+		if !strings.HasSuffix(key, "/") || !strings.HasSuffix(mapped, "/") ||
+			!strings.HasPrefix(specifier, key) || len(key) <= len(prefix) {
+			continue
+		}
+		prefix = key
+	}
+	if prefix == "" {
+		return "", false
+	}
+	return imports[prefix] + strings.TrimPrefix(specifier, prefix), true
+}
+
+// resolve returns the import map entry for specifier as seen from the
+// importer module. Scopes whose prefix matches the importer path are
+// consulted first, from the most specific one, before the top level
+// imports.
+func (r *importMapResolver) resolve(specifier, importer string) (string, bool) {
+	for _, scope := range r.scopes {
+		if !strings.HasPrefix(importer, scope.prefix) {
+			continue
+		}
+		if mapped, ok := lookupSpecifier(scope.imports, specifier); ok {
+			return mapped, true
+		}
+	}
+
+	return lookupSpecifier(r.imports, specifier)
+}
+
+// checkIntegrity verifies that the contents of the file at path hash to
+// expected.
+func checkIntegrity(path, expected string) error {
+	algorithm, _, _ := strings.Cut(expected, "-")
+	if algorithm != "sha256" {
+		return fmt.Errorf("unsupported integrity '%s' for %s, expected "+
+			"sha256-<base64>", expected, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	actual := "sha256-" + base64.StdEncoding.EncodeToString(sum[:])
+	if actual != expected {
+		return fmt.Errorf("integrity check failed for %s: expected %s, "+
+			"got %s", path, expected, actual)
+	}
+	return nil
+}
+
+// urlSchemeRe matches the scheme of a URL, like "http:" or "data:". A
+// Windows drive letter, as in "C:\", looks the same.
+var urlSchemeRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+
+// isBareSpecifier reports whether specifier is to be looked up in the
+// import map. Following the import map spec it isn't if it is a relative
+// or an absolute path, including Windows ones, or a URL.
+func isBareSpecifier(specifier string) bool {
+	switch {
+	case specifier == "", specifier == ".", specifier == "..":
+		return false
+	case strings.HasPrefix(specifier, "/"),
+		strings.HasPrefix(specifier, "\\"),
+		strings.HasPrefix(specifier, "./"),
+		strings.HasPrefix(specifier, "../"),
+		strings.HasPrefix(specifier, ".\\"),
+		strings.HasPrefix(specifier, "..\\"):
+		return false
+	case urlSchemeRe.MatchString(specifier):
+		return false
+	}
+	return true
+}
+
+func isExternal(specifier string, externals []string) bool {
+	for _, external := range externals {
+		if prefix, suffix, found := strings.Cut(external, "*"); found {
+			if len(specifier) >= len(prefix)+len(suffix) &&
+				strings.HasPrefix(specifier, prefix) &&
+				strings.HasSuffix(specifier, suffix) {
+				return true
+			}
+			continue
+		}
+		if specifier == external ||
+			strings.HasPrefix(specifier, external+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// importMapState holds the import map merged from the files at paths. The
+// files are checked for changes before every build, so that rebuilds in
+// watch mode pick up an edited import map. OnResolve callbacks run
+// concurrently, hence the lock. With verify, every reloaded map is checked
+// for entries pointing at missing files. With quiet, an empty map isn't
+// warned about.
+type importMapState struct {
+	paths  []string
+	inDir  string
+	verify bool
+	quiet  bool
+
+	mu       sync.RWMutex
+	modTimes []time.Time
+	resolver *importMapResolver
+}
+
+// resolveHook, if set, is called with every bare specifier the import map
+// plugin resolves. The tests use it to make the plugin panic.
+var resolveHook func(specifier string)
+
+// ImportMapStdin is the import map path that reads the map from stdin.
+const ImportMapStdin = "-"
+
+// importMapFetchTimeout bounds fetching an import map from a URL.
+const importMapFetchTimeout = 30 * time.Second
+
+// isImportMapFile reports whether the import map at path is a file, rather
+// than read from stdin or fetched from a URL. Only files are watched for
+// changes, the others are read once.
+func isImportMapFile(path string) bool {
+	return path != ImportMapStdin && !strings.HasPrefix(path, "http://") &&
+		!strings.HasPrefix(path, "https://")
+}
+
+// importMapFiles returns the paths that are files.
+func importMapFiles(paths []string) []string {
+	var files []string
+	for _, path := range paths {
+		if isImportMapFile(path) {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// fetchImportMap fetches the import map at url.
+func fetchImportMap(url string) ([]byte, error) {
+	client := http.Client{Timeout: importMapFetchTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server replied %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// readImportMapData reads the import map at path, which is a file, a URL
+// or ImportMapStdin.
+func readImportMapData(path string) ([]byte, error) {
+	switch {
+	case path == ImportMapStdin:
+		return readStdin()
+	case !isImportMapFile(path):
+		return fetchImportMap(path)
+	}
+	return os.ReadFile(path)
+}
+
+func readImportMap(path string) (ImportMap, error) {
+	var importmap ImportMap
+
+	plan, err := readImportMapData(path)
+	if err != nil {
+		return importmap, fmt.Errorf("cannot read import map at %s: %s",
+			path, err.Error())
+	}
+	// catch typos like "import", which would leave the map empty
+	decoder := json.NewDecoder(bytes.NewReader(plan))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&importmap); err != nil {
+		return importmap, fmt.Errorf("cannot parse import map at %s: %s",
+			path, err.Error())
+	}
+	return importmap, nil
+}
+
+func mergeEntries(dst *map[string]string, src map[string]string) {
+	if *dst == nil {
+		*dst = make(map[string]string)
+	}
+	for key, value := range src {
+		(*dst)[key] = value
+	}
+}
+
+// mergeImportMaps merges the import maps left to right: the entries of a
+// later map replace those of an earlier one with the same key, scopes are
+// merged entry by entry.
+func mergeImportMaps(importmaps []ImportMap) ImportMap {
+	var merged ImportMap
+	for _, importmap := range importmaps {
+		mergeEntries(&merged.Imports, importmap.Imports)
+		mergeEntries(&merged.Integrity, importmap.Integrity)
+		for key, imports := range importmap.Scopes {
+			if merged.Scopes == nil {
+				merged.Scopes = make(map[string]map[string]string)
+			}
+			scope := merged.Scopes[key]
+			mergeEntries(&scope, imports)
+			merged.Scopes[key] = scope
+		}
+	}
+	return merged
+}
+
+// verifyImportMap checks that the files, or dirs for entries ending in '/',
+// the entries of importmap point at exist, and reports all that don't.
+func verifyImportMap(importmap ImportMap, inDir string) error {
+	var missing []string
+	check := func(scope string, imports map[string]string) {
+		for specifier, mapped := range imports {
+			path := importMapPath(inDir, mapped)
+			info, err := os.Stat(path)
+			if err == nil && info.IsDir() == strings.HasSuffix(mapped, "/") {
+				continue
+			}
+			entry := fmt.Sprintf("%s -> %s", specifier, path)
+			if scope != "" {
+				entry = fmt.Sprintf("%s (in scope %s)", entry, scope)
+			}
+			missing = append(missing, entry)
+		}
+	}
+	check("", importmap.Imports)
+	for scope, imports := range importmap.Scopes {
+		check(scope, imports)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+	return fmt.Errorf("import map entries point at missing files:\n  %s",
+		strings.Join(missing, "\n  "))
+}
+
+func (s *importMapState) reloadIfChanged() error {
+	modTimes := make([]time.Time, len(s.paths))
+	for i, path := range s.paths {
+		if !isImportMapFile(path) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("cannot read import map at %s: %s",
+				path, err.Error())
+		}
+		modTimes[i] = info.ModTime()
+	}
+
+	s.mu.RLock()
+	unchanged := s.resolver != nil &&
+		slices.EqualFunc(modTimes, s.modTimes, time.Time.Equal)
+	s.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	importmaps := make([]ImportMap, 0, len(s.paths))
+	for _, path := range s.paths {
+		importmap, err := readImportMap(path)
+		if err != nil {
+			return err
+		}
+		importmaps = append(importmaps, importmap)
+	}
+
+	merged := mergeImportMaps(importmaps)
+	if len(merged.Imports) == 0 {
+		logWarning(s.quiet, "Warning: import map at %s has no imports\n",
+			strings.Join(s.paths, ", "))
+	}
+	if s.verify {
+		if err := verifyImportMap(merged, s.inDir); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	s.resolver = newImportMapResolver(merged, s.inDir)
+	s.modTimes = modTimes
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *importMapState) resolve(specifier, importer string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.resolver.resolve(specifier, importer)
+}
+
+func (s *importMapState) expectedIntegrity(path string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hash, ok := s.resolver.integrity[path]
+	return hash, ok
+}
+
+// aliasResolution marks the resolutions the import map plugin asks esbuild
+// for, which it leaves alone.
+type aliasResolution struct{}
+
+// resolveAlias lets esbuild resolve a specifier missing from the import
+// map, which is accepted if it is an alias of a file in the source tree,
+// e.g. from the paths of a tsconfig.json, rather than a package.
+func resolveAlias(build api.PluginBuild,
+	args api.OnResolveArgs) (api.OnResolveResult, bool) {
+	resolved := build.Resolve(args.Path, api.ResolveOptions{
+		Importer:   args.Importer,
+		Namespace:  args.Namespace,
+		ResolveDir: args.ResolveDir,
+		Kind:       args.Kind,
+		PluginData: aliasResolution{},
+		With:       args.With,
+	})
+	if len(resolved.Errors) > 0 || resolved.External ||
+		slices.Contains(strings.Split(filepath.ToSlash(resolved.Path), "/"),
+			"node_modules") {
+		return api.OnResolveResult{}, false
+	}
+	return api.OnResolveResult{
+		Path:      resolved.Path,
+		Namespace: resolved.Namespace,
+		Suffix:    resolved.Suffix,
+	}, true
+}
+
+// getImportMapPlugin resolves bare specifiers through the import maps at
+// importmapPaths, merged in order. The passThrough specifiers, which may
+// contain a '*' wildcard, and their subpaths are left to esbuild, and so
+// are those missing from the maps unless strict. With tsconfigPaths, for a
+// -tsconfig whose paths aliases esbuild applies, those that esbuild
+// resolves to a file outside of node_modules are accepted even if strict. With verify, the maps are checked for entries pointing at missing
+// files before every build.
+func getImportMapPlugin(importmapPaths []string, inDir string,
+	passThrough []string, strict, tsconfigPaths, verify,
+	quiet bool) (api.Plugin, error) {
+	state := &importMapState{
+		paths:  importmapPaths,
+		inDir:  inDir,
+		verify: verify,
+		quiet:  quiet,
+	}
+	if err := state.reloadIfChanged(); err != nil {
+		return api.Plugin{}, err
+	}
+	watchFiles := importMapFiles(importmapPaths)
+
+	return api.Plugin{
+		Name: "ImportMap",
+		Setup: func(build api.PluginBuild) {
+			build.OnStart(func() (api.OnStartResult, error) {
+				return api.OnStartResult{}, state.reloadIfChanged()
+			})
+			build.OnResolve(api.OnResolveOptions{Filter: `.*`},
+				func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+					if _, ok := args.PluginData.(aliasResolution); ok {
+						return api.OnResolveResult{}, nil
+					}
+					if !isBareSpecifier(args.Path) {
+						return api.OnResolveResult{}, nil
+					}
+					if args.Kind == api.ResolveCSSImportRule ||
+						args.Kind == api.ResolveCSSURLToken {
+						// plain paths in css are relative, not bare
+						return api.OnResolveResult{}, nil
+					}
+					if isExternal(args.Path, passThrough) {
+						return api.OnResolveResult{}, nil
+					}
+					if resolveHook != nil {
+						resolveHook(args.Path)
+					}
+					mapped, ok := state.resolve(args.Path, args.Importer)
+					if !ok && !strict {
+						return api.OnResolveResult{}, nil
+					}
+					if !ok && tsconfigPaths {
+						if result, ok := resolveAlias(build, args); ok {
+							return result, nil
+						}
+					}
+					if !ok {
+						return api.OnResolveResult{
+							Errors: []api.Message{{
+								Text: fmt.Sprintf("bare specifier \"%s\" "+
+									"imported by %s is missing from the "+
+									"import map", args.Path, args.Importer),
+							}},
+						}, nil
+					}
+					return api.OnResolveResult{
+						Path:       importMapPath(state.inDir, mapped),
+						WatchFiles: watchFiles,
+					}, nil
+				})
+			build.OnLoad(api.OnLoadOptions{Filter: `.*`, Namespace: "file"},
+				func(args api.OnLoadArgs) (api.OnLoadResult, error) {
+					expected, ok := state.expectedIntegrity(args.Path)
+					if !ok {
+						return api.OnLoadResult{}, nil
+					}
+					if err := checkIntegrity(args.Path, expected); err != nil {
+						return api.OnLoadResult{
+							Errors: []api.Message{{Text: err.Error()}},
+						}, nil
+					}
+					// let esbuild load the file as usual
+					return api.OnLoadResult{}, nil
+				})
+		},
+	}, nil
+}
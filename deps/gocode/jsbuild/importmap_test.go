@@ -0,0 +1,450 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsBareSpecifier(t *testing.T) {
+	for specifier, expected := range map[string]bool{
+		"a":              true,
+		"lib":            true,
+		"lib/sub.js":     true,
+		"@scope/pkg":     true,
+		"@scope/pkg/sub": true,
+		"./rel":          false,
+		"../x":           false,
+		".\\rel":         false,
+		"..\\x":          false,
+		".":              false,
+		"..":             false,
+		"":               false,
+		"/abs":           false,
+		"\\abs":          false,
+		"http://x":       false,
+		"data:text/js,1": false,
+		"C:\\x":          false,
+		"c:/x":           false,
+	} {
+		if bare := isBareSpecifier(specifier); bare != expected {
+			t.Errorf("isBareSpecifier(%q) = %v, expected %v", specifier,
+				bare, expected)
+		}
+	}
+}
+
+func TestStrictImportMap(t *testing.T) {
+	files := map[string]string{
+		"ui/importmap.json":     `{"imports": {"lib": "./web_modules/lib.js"}}`,
+		"ui/web_modules/lib.js": `export const lib = "from the map";`,
+		"ui/app/node_modules/unmapped/index.js": `
+			export const unmapped = "from node_modules";`,
+		"ui/app/main.js": `import { lib } from "lib";
+			import { unmapped } from "unmapped";
+			console.info(lib, unmapped);`,
+	}
+
+	t.Run("lenient", func(t *testing.T) {
+		cfg := newTestTree(t, files)
+		cfg.StrictImportMap = false
+
+		result := mustBuild(t, cfg)
+
+		main := output(t, result, cfg.OutDir, "main.js")
+		for _, text := range []string{"from the map", "from node_modules"} {
+			if !strings.Contains(main, text) {
+				t.Errorf("%q is missing from main.js:\n%s", text, main)
+			}
+		}
+	})
+
+	// strict is the default
+	t.Run("strict", func(t *testing.T) {
+		cfg := newTestTree(t, files)
+
+		result := mustRun(t, cfg)
+
+		if len(result.Errors) != 1 ||
+			!strings.HasPrefix(result.Errors[0].Text,
+				`bare specifier "unmapped" imported by `+cfg.InDir) ||
+			!strings.HasSuffix(result.Errors[0].Text,
+				"main.js is missing from the import map") {
+			t.Errorf("unexpected errors: %v", messageTexts(result.Errors))
+		}
+	})
+}
+
+func TestImportMapScopes(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/importmap.json": `{
+			"imports": {"lib": "./web_modules/lib.js"},
+			"scopes": {
+				"./admin/": {"lib": "./admin/lib.js"},
+				"./admin/deep/": {"lib": "./admin/deep/lib.js"}
+			}
+		}`,
+		"ui/web_modules/lib.js": `export const lib = "top level lib";`,
+		"ui/admin/lib.js":       `export const lib = "admin lib";`,
+		"ui/admin/deep/lib.js":  `export const lib = "deep admin lib";`,
+		"ui/admin/page.js": `import { lib } from "lib";
+			export const admin = lib;`,
+		"ui/admin/deep/page.js": `import { lib } from "lib";
+			export const deep = lib;`,
+		"ui/app/main.js": `import { lib } from "lib";
+			import { admin } from "../admin/page.js";
+			import { deep } from "../admin/deep/page.js";
+			console.info(lib, admin, deep);`,
+	})
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	for _, lib := range []string{"top level lib", "admin lib",
+		"deep admin lib"} {
+		if !strings.Contains(main, `"`+lib+`"`) {
+			t.Errorf("%q is missing from main.js:\n%s", lib, main)
+		}
+	}
+}
+
+func TestImportMapMissingSpecifier(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/importmap.json":     `{"imports": {"lib": "./web_modules/lib.js"}}`,
+		"ui/web_modules/lib.js": `export const lib = 1;`,
+		"ui/app/main.js": `import { lib } from "lib";
+			import { other } from "other";
+			console.info(lib, other);`,
+	})
+
+	result := mustRun(t, cfg)
+
+	if len(result.Errors) != 1 {
+		t.Fatalf("expected one error, got %v", messageTexts(result.Errors))
+	}
+	importer := filepath.Join(cfg.InDir, "ui", "app", "main.js")
+	expected := `bare specifier "other" imported by ` + importer +
+		` is missing from the import map`
+	if msg := result.Errors[0]; msg.Text != expected {
+		t.Errorf("unexpected error %q, expected %q", msg.Text, expected)
+	} else if msg.Location == nil || msg.Location.Line != 2 {
+		t.Errorf("the error doesn't point at the import: %+v", msg.Location)
+	}
+}
+
+func TestExternals(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/importmap.json": `{"imports": {
+			"jquery": "./web_modules/jquery.js",
+			"lib": "./web_modules/lib.js"
+		}}`,
+		"ui/web_modules/jquery.js": `export default "bundled jquery";`,
+		"ui/web_modules/lib.js":    `export default "bundled lib";`,
+		"ui/app/main.js": `import $ from "jquery";
+			import plugin from "jquery/plugin";
+			import lib from "lib";
+			import widget from "@acme/widget";
+			console.info($, plugin, lib, widget);`,
+	})
+	cfg.Externals = []string{"jquery", "@acme/*"}
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	for _, specifier := range []string{"jquery", "jquery/plugin",
+		"@acme/widget"} {
+		if !strings.Contains(main, `from"`+specifier+`"`) {
+			t.Errorf("%s is not kept as an import:\n%s", specifier, main)
+		}
+	}
+	if strings.Contains(main, "bundled jquery") {
+		t.Errorf("the external is resolved through the import map:\n%s",
+			main)
+	}
+	if !strings.Contains(main, "bundled lib") {
+		t.Errorf("lib is not bundled:\n%s", main)
+	}
+}
+
+func TestImportMapIntegrity(t *testing.T) {
+	const lib = `export const lib = "vendored";`
+	sum := sha256.Sum256([]byte(lib))
+	hash := "sha256-" + base64.StdEncoding.EncodeToString(sum[:])
+	wrong := "sha256-" + base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	for _, integrity := range []string{hash, wrong} {
+		cfg := newTestTree(t, map[string]string{
+			"ui/importmap.json": fmt.Sprintf(`{
+				"imports": {"lib": "./web_modules/lib.js"},
+				"integrity": {"./web_modules/lib.js": %q}
+			}`, integrity),
+			"ui/web_modules/lib.js": lib,
+			"ui/app/main.js": `import { lib } from "lib";
+				console.info(lib);`,
+		})
+
+		result := mustRun(t, cfg)
+
+		if integrity == hash {
+			if len(result.Errors) > 0 {
+				t.Errorf("the build with the right hash fails: %v",
+					messageTexts(result.Errors))
+			}
+			continue
+		}
+		path := filepath.Join(cfg.InDir, "ui", "web_modules", "lib.js")
+		expected := "integrity check failed for " + path + ": expected " +
+			wrong + ", got " + hash
+		if len(result.Errors) != 1 || result.Errors[0].Text != expected {
+			t.Errorf("unexpected errors %q, expected %q",
+				messageTexts(result.Errors), expected)
+		}
+	}
+}
+
+func TestMergedImportMaps(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/importmap.json": `{
+			"imports": {
+				"lib": "./web_modules/lib.js",
+				"theme": "./web_modules/theme.js"
+			},
+			"scopes": {"./admin/": {
+				"lib": "./admin/lib.js",
+				"theme": "./admin/theme.js"
+			}}
+		}`,
+		"ui/enterprise.json": `{
+			"imports": {"theme": "./enterprise/theme.js"},
+			"scopes": {"./admin/": {"theme": "./enterprise/admin.js"}}
+		}`,
+		"ui/web_modules/lib.js":   `export default "base lib";`,
+		"ui/web_modules/theme.js": `export default "base theme";`,
+		"ui/enterprise/theme.js":  `export default "enterprise theme";`,
+		"ui/admin/lib.js":         `export default "admin lib";`,
+		"ui/admin/theme.js":       `export default "admin theme";`,
+		"ui/enterprise/admin.js":  `export default "enterprise admin theme";`,
+		"ui/admin/page.js": `import lib from "lib";
+			import theme from "theme";
+			export default [lib, theme];`,
+		"ui/app/main.js": `import lib from "lib";
+			import theme from "theme";
+			import page from "../admin/page.js";
+			console.info(lib, theme, page);`,
+	})
+	cfg.ImportMapPaths = append(cfg.ImportMapPaths,
+		filepath.Join(cfg.InDir, "ui", "enterprise.json"))
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	for _, text := range []string{"base lib", "enterprise theme",
+		"admin lib", "enterprise admin theme"} {
+		if !strings.Contains(main, `"`+text+`"`) {
+			t.Errorf("%q is missing from main.js:\n%s", text, main)
+		}
+	}
+	for _, text := range []string{"base theme", "admin theme"} {
+		if strings.Contains(main, `"`+text+`"`) {
+			t.Errorf("the overridden %q is in main.js:\n%s", text, main)
+		}
+	}
+}
+
+func TestReadImportMapUnknownKey(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"typo.json":  `{"import": {"lib": "./lib.js"}}`,
+		"valid.json": `{"imports": {"lib": "./lib.js"}}`,
+	})
+
+	path := filepath.Join(dir, "typo.json")
+	_, err := readImportMap(path)
+	expected := "cannot parse import map at " + path +
+		`: json: unknown field "import"`
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+
+	importmap, err := readImportMap(filepath.Join(dir, "valid.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if importmap.Imports["lib"] != "./lib.js" {
+		t.Errorf("unexpected import map %+v", importmap)
+	}
+}
+
+func TestImportMapPrefixes(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/importmap.json": `{"imports": {
+			"lodash/": "./vendor/lodash/",
+			"lodash/fp": "./vendor/fp.js",
+			"lodash/fp/": "./vendor/fp/",
+			"exact": "./vendor/exact.js"
+		}}`,
+		"ui/vendor/lodash/map.js": `export default "lodash map";`,
+		"ui/vendor/lodash/fp.js":  `export default "lodash prefix fp";`,
+		"ui/vendor/fp.js":         `export default "exact fp";`,
+		"ui/vendor/fp/curry.js":   `export default "longest prefix curry";`,
+		"ui/vendor/lodash/fp/curry.js": `
+			export default "short prefix curry";`,
+		"ui/vendor/exact.js": `export default "exact";`,
+		"ui/app/main.js": `import map from "lodash/map.js";
+			import fp from "lodash/fp";
+			import curry from "lodash/fp/curry.js";
+			import exact from "exact";
+			console.info(map, fp, curry, exact);`,
+	})
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	for _, text := range []string{"lodash map", "exact fp",
+		"longest prefix curry", "exact"} {
+		if !strings.Contains(main, `"`+text+`"`) {
+			t.Errorf("%q is missing from main.js:\n%s", text, main)
+		}
+	}
+	for _, text := range []string{"lodash prefix fp", "short prefix curry"} {
+		if strings.Contains(main, text) {
+			t.Errorf("%q is resolved through a shorter prefix:\n%s", text,
+				main)
+		}
+	}
+}
+
+func TestImportMapAbsoluteEntries(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/importmap.json": `{"imports": {
+			"lib": "./web_modules/lib.js",
+			"generated": "/generated/messages.js"
+		}}`,
+		"ui/web_modules/lib.js": `export default "relative to ui";`,
+		"generated/messages.js": `export default "relative to the tree";`,
+		"ui/generated/messages.js": `
+			export default "wrongly relative to ui";`,
+		"ui/app/main.js": `import lib from "lib";
+			import generated from "generated";
+			console.info(lib, generated);`,
+	})
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	for _, text := range []string{"relative to ui", "relative to the tree"} {
+		if !strings.Contains(main, `"`+text+`"`) {
+			t.Errorf("%q is missing from main.js:\n%s", text, main)
+		}
+	}
+	if strings.Contains(main, "wrongly") {
+		t.Errorf("the absolute entry is joined with ui:\n%s", main)
+	}
+}
+
+func TestImportMapWithNodePaths(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/importmap.json":     `{"imports": {"lib": "./web_modules/lib.js"}}`,
+		"ui/web_modules/lib.js": `export const lib = "from the map";`,
+		"vendor/vendored/index.js": `
+			export const vendored = "from the node path";`,
+		"ui/app/main.js": `import { lib } from "lib";
+			import { vendored } from "vendored";
+			console.info(lib, vendored);`,
+	})
+	cfg.NodePaths = []string{filepath.Join(cfg.InDir, "vendor")}
+	cfg.StrictImportMap = false
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	for _, text := range []string{"from the map", "from the node path"} {
+		if !strings.Contains(main, text) {
+			t.Errorf("%q is missing from main.js:\n%s", text, main)
+		}
+	}
+}
+
+func TestVerifyImportMap(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/importmap.json": `{"imports": {
+			"lib": "./web_modules/lib.js",
+			"gone": "./web_modules/gone.js"
+		}}`,
+		"ui/web_modules/lib.js": `export const lib = 1;`,
+		"ui/app/main.js":        `console.info("main");`,
+	})
+	cfg.VerifyImportMap = true
+
+	_, err := Run(cfg)
+
+	gone := filepath.Join(cfg.InDir, "ui", "web_modules", "gone.js")
+	expected := "import map entries point at missing files:\n" +
+		"  gone -> " + gone
+	if err == nil || err.Error() != expected {
+		t.Errorf("unexpected error %v, expected %q", err, expected)
+	}
+}
+
+// remoteMapTree has main.js import lib, mapped by an import map that isn't
+// in the tree.
+var remoteMapTree = map[string]string{
+	"ui/web_modules/lib.js": `export const lib = "the mapped lib";`,
+	"ui/app/main.js":        `import { lib } from "lib"; console.info(lib);`,
+}
+
+const remoteMap = `{"imports": {"lib": "./web_modules/lib.js"}}`
+
+func TestImportMapStdin(t *testing.T) {
+	cfg := newTestTree(t, remoteMapTree)
+	cfg.ImportMapPaths = pathList{ImportMapStdin}
+	setStdin(t, remoteMap)
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, "the mapped lib") {
+		t.Errorf("the map from stdin isn't used:\n%s", main)
+	}
+}
+
+func TestImportMapURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/importmap.json" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, remoteMap)
+		}))
+	defer server.Close()
+
+	cfg := newTestTree(t, remoteMapTree)
+	cfg.ImportMapPaths = pathList{server.URL + "/importmap.json"}
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, "the mapped lib") {
+		t.Errorf("the map from the URL isn't used:\n%s", main)
+	}
+
+	cfg.ImportMapPaths = pathList{server.URL + "/missing.json"}
+	_, err := Run(cfg)
+	if err == nil || !strings.Contains(err.Error(), "404 Not Found") {
+		t.Errorf("expected the reply to be reported, got %v", err)
+	}
+}
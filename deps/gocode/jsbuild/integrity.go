@@ -0,0 +1,79 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+const IntegrityFileName = "integrity.json"
+
+// writeIntegrityFile computes subresource integrity hashes of all .js and
+// .css files in outDir and writes them to outDir/integrity.json, keyed by
+// the path relative to outDir.
+func writeIntegrityFile(outDir string) error {
+	hashes := make(map[string]string)
+
+	err := filepath.WalkDir(outDir,
+		func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !(isJSOutput(path) ||
+				filepath.Ext(path) == ".css") {
+				return nil
+			}
+
+			contents, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(outDir, path)
+			if err != nil {
+				return err
+			}
+			sum := sha512.Sum384(contents)
+			hashes[filepath.ToSlash(rel)] = "sha384-" +
+				base64.StdEncoding.EncodeToString(sum[:])
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(hashes, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outDir, IntegrityFileName), data, 0644)
+}
+
+// getIntegrityPlugin regenerates the integrity file after every successful
+// build. OnEnd callbacks run once esbuild has written the output files, so
+// the hashes match the bytes on disk.
+func getIntegrityPlugin(outDir string) api.Plugin {
+	return api.Plugin{
+		Name: "Integrity",
+		Setup: func(build api.PluginBuild) {
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				if len(result.Errors) > 0 {
+					return api.OnEndResult{}, nil
+				}
+				return api.OnEndResult{}, writeIntegrityFile(outDir)
+			})
+		},
+	}
+}
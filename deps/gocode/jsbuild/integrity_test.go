@@ -0,0 +1,43 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestIntegrity(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("main");`,
+	})
+	cfg.Integrity = true
+
+	mustBuild(t, cfg)
+
+	var hashes map[string]string
+	data := readFile(t, filepath.Join(cfg.OutDir, IntegrityFileName))
+	if err := json.Unmarshal([]byte(data), &hashes); err != nil {
+		t.Fatalf("can't parse %s: %v", IntegrityFileName, err)
+	}
+
+	main := readFile(t, filepath.Join(cfg.OutDir, "main.js"))
+	if main != `console.info("main");`+"\n" {
+		t.Fatalf("unexpected main.js: %q", main)
+	}
+	sum := sha512.Sum384([]byte(main))
+	expected := "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+	if len(hashes) != 1 || hashes["main.js"] != expected {
+		t.Errorf("unexpected hashes %v, expected main.js: %s", hashes,
+			expected)
+	}
+}
@@ -0,0 +1,167 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+const ManifestFileName = "manifest.json"
+
+// The shapes a manifest can be written in.
+const (
+	// entry point -> output path
+	ManifestSchemaFlat = "flat"
+	// entry point -> {file, css, imports}, as webpack and vite write them
+	ManifestSchemaWebpack = "webpack"
+)
+
+// webpackManifestEntry describes the output of an entry point in a
+// ManifestSchemaWebpack manifest: the entry point's output, its css bundle
+// and the chunks it imports, which can be preloaded.
+type webpackManifestEntry struct {
+	File    string   `json:"file"`
+	CSS     []string `json:"css,omitempty"`
+	Imports []string `json:"imports,omitempty"`
+}
+
+// getManifestPath returns the path cfg has the manifest written to, if any:
+// -write-manifest, or manifest.json in -out-dir when the output file names
+// are templated.
+func getManifestPath(cfg Options) string {
+	if cfg.ManifestPath == "" && cfg.EntryNames != "" {
+		return filepath.Join(cfg.OutDir, ManifestFileName)
+	}
+	return cfg.ManifestPath
+}
+
+// checkManifestSchema checks that schema is one of the manifest schemas.
+func checkManifestSchema(schema string) error {
+	switch schema {
+	case ManifestSchemaFlat, ManifestSchemaWebpack:
+		return nil
+	}
+	return fmt.Errorf("unknown manifest schema '%s'", schema)
+}
+
+// namedOutputPattern matches the paths, relative to the out dir, that
+// esbuild names the output of an entry point given as name=path, expanding
+// entryNames as it does: [dir] and [name] from name, with any hash and
+// extension.
+func namedOutputPattern(entryNames, name string) *regexp.Regexp {
+	if entryNames == "" {
+		entryNames = "[dir]/[name]"
+	}
+	dir, base := path.Split(name)
+	const hash, ext = "\x00hash\x00", "\x00ext\x00"
+	expanded := strings.NewReplacer("[dir]", dir, "[name]", base,
+		"[hash]", hash, "[ext]", ext).Replace(entryNames)
+	expanded = strings.TrimPrefix(path.Clean(expanded), "/")
+	pattern := strings.NewReplacer(hash, "[0-9A-Z]+", ext, "[^/]+").Replace(
+		regexp.QuoteMeta(expanded))
+	return regexp.MustCompile("^" + pattern + `\.[^./]+$`)
+}
+
+// getManifestKey returns the key of the output at file, relative to the out
+// dir, of the entry point at abs: the name it is given as name=path in
+// entries, or else entry, its path relative to the in dir. Several entries
+// can name the same file.
+func getManifestKey(entries []api.EntryPoint, entryNames, abs, entry,
+	file string) string {
+	for _, named := range entries {
+		if named.OutputPath == "" ||
+			filepath.Clean(named.InputPath) != abs {
+			continue
+		}
+		if namedOutputPattern(entryNames, named.OutputPath).
+			MatchString(file) {
+			return named.OutputPath
+		}
+	}
+	return entry
+}
+
+// formatManifest renders the manifest of the build described by meta in
+// schema, with entry points relative to inDir and outputs to outDir. The
+// entry points given as name=path in entries are keyed by their name.
+func formatManifest(meta metafile, schema, workingDir, inDir, outDir string,
+	entries []api.EntryPoint, entryNames string) ([]byte, error) {
+	flat := make(map[string]string)
+	webpack := make(map[string]webpackManifestEntry)
+	for path, output := range meta.Outputs {
+		if output.EntryPoint == "" {
+			continue
+		}
+		file := relMetafilePath(workingDir, path, outDir)
+		entry := getManifestKey(entries, entryNames,
+			filepath.Join(workingDir, output.EntryPoint),
+			relMetafilePath(workingDir, output.EntryPoint, inDir), file)
+		flat[entry] = file
+
+		item := webpackManifestEntry{File: file}
+		if output.CSSBundle != "" {
+			item.CSS = []string{
+				relMetafilePath(workingDir, output.CSSBundle, outDir)}
+		}
+		for _, imp := range output.Imports {
+			if imp.External || imp.Kind != "import-statement" {
+				continue
+			}
+			item.Imports = append(item.Imports,
+				relMetafilePath(workingDir, imp.Path, outDir))
+		}
+		webpack[entry] = item
+	}
+
+	if schema == ManifestSchemaWebpack {
+		return json.MarshalIndent(webpack, "", "  ")
+	}
+	return json.MarshalIndent(flat, "", "  ")
+}
+
+// getManifestPlugin writes a manifest to manifestPath after every
+// successful build. It maps every entry point, relative to inDir or by the
+// name it is given, to the path of its output relative to outDir, in the
+// shape schema says, so hashed file names can be looked up.
+func getManifestPlugin(inDir, outDir, manifestPath,
+	schema string) api.Plugin {
+	return api.Plugin{
+		Name: "Manifest",
+		Setup: func(build api.PluginBuild) {
+			workingDir := getWorkingDir(build.InitialOptions)
+
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				if len(result.Errors) > 0 {
+					return api.OnEndResult{}, nil
+				}
+
+				meta, err := parseMetafile(result.Metafile)
+				if err != nil {
+					return api.OnEndResult{}, err
+				}
+				data, err := formatManifest(meta, schema, workingDir, inDir,
+					outDir, build.InitialOptions.EntryPointsAdvanced,
+					build.InitialOptions.EntryNames)
+				if err != nil {
+					return api.OnEndResult{}, err
+				}
+				err = os.WriteFile(manifestPath, data, 0644)
+				return api.OnEndResult{}, err
+			})
+		},
+	}
+}
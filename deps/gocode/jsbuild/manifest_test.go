@@ -0,0 +1,145 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// readManifest parses the manifest.json that cfg writes.
+func readManifest(t *testing.T, cfg Options, manifest any) {
+	t.Helper()
+	data := readFile(t, getManifestPath(cfg))
+	if err := json.Unmarshal([]byte(data), manifest); err != nil {
+		t.Fatalf("can't parse the manifest: %v\n%s", err, data)
+	}
+}
+
+// hashedBuild builds main.js of a new test tree with hashed entry names
+// and returns the hashed name from the manifest.
+func hashedBuild(t *testing.T, main string) string {
+	t.Helper()
+	cfg := newTestTree(t, map[string]string{"ui/app/main.js": main})
+	cfg.EntryNames = "[name]-[hash]"
+
+	mustBuild(t, cfg)
+
+	var manifest map[string]string
+	readManifest(t, cfg, &manifest)
+	name, ok := manifest["ui/app/main.js"]
+	if len(manifest) != 1 || !ok {
+		t.Fatalf("unexpected manifest %v", manifest)
+	}
+	if !regexp.MustCompile(`^main-[0-9A-Z]{8}\.js$`).MatchString(name) {
+		t.Fatalf("%s isn't a hashed name", name)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.OutDir, name)); err != nil {
+		t.Fatalf("the manifest entry isn't written: %v", err)
+	}
+	return name
+}
+
+func TestHashedEntryNames(t *testing.T) {
+	first := hashedBuild(t, `console.info("main");`)
+
+	if second := hashedBuild(t, `console.info("main");`); second != first {
+		t.Errorf("identical builds are named %s and %s", first, second)
+	}
+	if changed := hashedBuild(t, `console.info("changed");`); changed == first {
+		t.Errorf("a different build is also named %s", first)
+	}
+}
+
+// manifestBuild builds the two entry points of sharedCodeTree, writing the
+// manifest in schema, and returns the options and the shared chunk.
+func manifestBuild(t *testing.T, schema string) (Options, string) {
+	t.Helper()
+	cfg := newTestTree(t, sharedCodeTree)
+	cfg.EntryPoints = []string{"ui/app/main.js", "ui/app/admin.js"}
+	cfg.ManifestPath = filepath.Join(cfg.InDir, "manifest.json")
+	cfg.ManifestSchema = schema
+
+	result := mustBuild(t, cfg)
+
+	var chunks []string
+	for _, name := range jsOutputs(result) {
+		if name != "main.js" && name != "admin.js" {
+			chunks = append(chunks, name)
+		}
+	}
+	if len(chunks) != 1 {
+		t.Fatalf("expected a single shared chunk, got %v", chunks)
+	}
+	return cfg, chunks[0]
+}
+
+func TestManifestSchemaFlat(t *testing.T) {
+	cfg, _ := manifestBuild(t, ManifestSchemaFlat)
+
+	var manifest map[string]string
+	readManifest(t, cfg, &manifest)
+	expected := map[string]string{
+		"ui/app/main.js":  "main.js",
+		"ui/app/admin.js": "admin.js",
+	}
+	if !reflect.DeepEqual(manifest, expected) {
+		t.Errorf("unexpected manifest %v, expected %v", manifest, expected)
+	}
+}
+
+func TestManifestSchemaWebpack(t *testing.T) {
+	cfg, chunk := manifestBuild(t, ManifestSchemaWebpack)
+
+	var manifest map[string]webpackManifestEntry
+	readManifest(t, cfg, &manifest)
+	expected := map[string]webpackManifestEntry{
+		"ui/app/main.js":  {File: "main.js", Imports: []string{chunk}},
+		"ui/app/admin.js": {File: "admin.js", Imports: []string{chunk}},
+	}
+	if !reflect.DeepEqual(manifest, expected) {
+		t.Errorf("unexpected manifest %v, expected %v", manifest, expected)
+	}
+}
+
+func TestManifestNamedEntryPoints(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js":     `console.info("main");`,
+		"ui/admin/source.js": `console.info("admin");`,
+	})
+	cfg.EntryPoints = []string{"ui/app/main.js", "login=ui/app/main.js",
+		"nested/admin=ui/admin/source.js"}
+	cfg.EntryNames = "[dir]/[name]-[hash]"
+
+	mustBuild(t, cfg)
+
+	var manifest map[string]string
+	readManifest(t, cfg, &manifest)
+	for key, pattern := range map[string]string{
+		"ui/app/main.js": `^main-[0-9A-Z]{8}\.js$`,
+		"login":          `^login-[0-9A-Z]{8}\.js$`,
+		"nested/admin":   `^nested/admin-[0-9A-Z]{8}\.js$`,
+	} {
+		name := manifest[key]
+		if !regexp.MustCompile(pattern).MatchString(name) {
+			t.Errorf("unexpected %s entry %q in %v", key, name, manifest)
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(cfg.OutDir, name)); err != nil {
+			t.Errorf("the %s entry isn't written: %v", key, err)
+		}
+	}
+	if len(manifest) != 3 {
+		t.Errorf("expected 3 entries, got %v", manifest)
+	}
+}
@@ -0,0 +1,120 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// the last line of a js or css output that links its source map
+var sourceMappingURLRe = regexp.MustCompile(
+	`(//|/\*)# sourceMappingURL=(\S+?)( \*/)?\n?$`)
+
+// setSourceMappingURL points the source map link of an output at url, or
+// removes the link if url is empty. Inline maps are left alone.
+func setSourceMappingURL(contents []byte, url string) []byte {
+	m := sourceMappingURLRe.FindSubmatchIndex(contents)
+	if m == nil || bytes.HasPrefix(contents[m[4]:m[5]], []byte("data:")) {
+		return contents
+	}
+	if url == "" {
+		return contents[:m[0]]
+	}
+	link := string(contents[m[2]:m[3]]) + "# sourceMappingURL=" + url
+	if m[6] >= 0 {
+		link += " */"
+	}
+	return append(contents[:m[0]:m[0]], link+"\n"...)
+}
+
+// isWithinDir tells whether path is dir or inside of it.
+func isWithinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." &&
+		!strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// moveSourceMaps moves the .map files among the outputs of a build from
+// outDir to the same paths under mapDir, tracking the moves in written, and
+// updates the outputs that link them. The links are removed unless mapDir
+// is inside of outDir, as the maps would not be served otherwise.
+func moveSourceMaps(outDir, mapDir string, files []api.OutputFile,
+	written *writtenFiles) error {
+	moved := make(map[string]string)
+	for i, file := range files {
+		if filepath.Ext(file.Path) != ".map" {
+			continue
+		}
+		rel, err := filepath.Rel(outDir, file.Path)
+		if err != nil {
+			return err
+		}
+		newPath := filepath.Join(mapDir, rel)
+		if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(newPath, file.Contents, 0644); err != nil {
+			return err
+		}
+		if err := os.Remove(file.Path); err != nil {
+			return err
+		}
+		moved[file.Path] = newPath
+		written.move(file.Path, newPath)
+		files[i].Path = newPath
+	}
+
+	served := isWithinDir(mapDir, outDir)
+	for i, file := range files {
+		newPath, ok := moved[file.Path+".map"]
+		if !ok {
+			continue
+		}
+		var url string
+		if served {
+			rel, err := filepath.Rel(filepath.Dir(file.Path), newPath)
+			if err != nil {
+				return err
+			}
+			url = filepath.ToSlash(rel)
+		}
+		contents := setSourceMappingURL(file.Contents, url)
+		if err := os.WriteFile(file.Path, contents, 0644); err != nil {
+			return err
+		}
+		files[i].Contents = contents
+	}
+	return nil
+}
+
+// getMapDirPlugin moves the source maps of every successful build to
+// mapDir. It updates the outputs in the build result, so it has to come
+// before the plugins that use them.
+func getMapDirPlugin(outDir, mapDir string,
+	written *writtenFiles) api.Plugin {
+	return api.Plugin{
+		Name: "MapDir",
+		Setup: func(build api.PluginBuild) {
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				if len(result.Errors) > 0 {
+					return api.OnEndResult{}, nil
+				}
+				err := moveSourceMaps(outDir, mapDir, result.OutputFiles,
+					written)
+				return api.OnEndResult{}, err
+			})
+		},
+	}
+}
@@ -0,0 +1,64 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMapDir(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		mapDir  func(cfg Options) string
+		comment string
+	}{
+		{
+			"served",
+			func(cfg Options) string {
+				return filepath.Join(cfg.OutDir, "maps")
+			},
+			"//# sourceMappingURL=maps/main.js.map\n",
+		},
+		{
+			"not served",
+			func(cfg Options) string {
+				return filepath.Join(cfg.InDir, "maps")
+			},
+			"",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := newTestTree(t, map[string]string{
+				"ui/app/main.js": `console.info("main");`,
+			})
+			cfg.Sourcemap = "linked"
+			cfg.MapDir = test.mapDir(cfg)
+
+			mustBuild(t, cfg)
+
+			main := readFile(t, filepath.Join(cfg.OutDir, "main.js"))
+			expected := `console.info("main");` + "\n" + test.comment
+			if main != expected {
+				t.Errorf("unexpected main.js %q, expected %q", main,
+					expected)
+			}
+			sourcemap := readFile(t, filepath.Join(cfg.MapDir, "main.js.map"))
+			if !strings.Contains(sourcemap, `"mappings"`) {
+				t.Errorf("unexpected main.js.map:\n%s", sourcemap)
+			}
+			_, err := os.Stat(filepath.Join(cfg.OutDir, "main.js.map"))
+			if !os.IsNotExist(err) {
+				t.Errorf("main.js.map is left in the out dir: %v", err)
+			}
+		})
+	}
+}
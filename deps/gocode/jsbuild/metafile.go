@@ -0,0 +1,101 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// The subset of the esbuild metafile that is used by the reports built on
+// top of it. All paths are relative to the build's working dir.
+type metafile struct {
+	Inputs  map[string]metafileInput  `json:"inputs"`
+	Outputs map[string]metafileOutput `json:"outputs"`
+}
+
+type metafileInput struct {
+	Bytes   int              `json:"bytes"`
+	Imports []metafileImport `json:"imports"`
+}
+
+type metafileImport struct {
+	Path     string `json:"path"`
+	Kind     string `json:"kind"`
+	External bool   `json:"external"`
+	Original string `json:"original"`
+}
+
+type metafileOutput struct {
+	Bytes      int                            `json:"bytes"`
+	EntryPoint string                         `json:"entryPoint"`
+	CSSBundle  string                         `json:"cssBundle"`
+	Exports    []string                       `json:"exports"`
+	Inputs     map[string]metafileOutputInput `json:"inputs"`
+	Imports    []metafileImport               `json:"imports"`
+}
+
+type metafileOutputInput struct {
+	BytesInOutput int `json:"bytesInOutput"`
+}
+
+func parseMetafile(data string) (metafile, error) {
+	var meta metafile
+	err := json.Unmarshal([]byte(data), &meta)
+	return meta, err
+}
+
+// getWorkingDir returns the dir the metafile paths of a build with opts are
+// relative to.
+func getWorkingDir(opts *api.BuildOptions) string {
+	if opts.AbsWorkingDir != "" {
+		return opts.AbsWorkingDir
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "."
+	}
+	return wd
+}
+
+// relMetafilePath converts a metafile path to a slash separated path
+// relative to baseDir.
+func relMetafilePath(workingDir, path, baseDir string) string {
+	abs := filepath.Join(workingDir, path)
+	absBase, err := filepath.Abs(baseDir)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(absBase, abs)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// getMetafilePlugin writes the build metafile to metafilePath at the end of
+// every build that has no errors, so a failed (re)build leaves the previous
+// metafile in place.
+func getMetafilePlugin(metafilePath string) api.Plugin {
+	return api.Plugin{
+		Name: "Metafile",
+		Setup: func(build api.PluginBuild) {
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				if len(result.Errors) > 0 {
+					return api.OnEndResult{}, nil
+				}
+				err := os.WriteFile(metafilePath, []byte(result.Metafile), 0644)
+				return api.OnEndResult{}, err
+			})
+		},
+	}
+}
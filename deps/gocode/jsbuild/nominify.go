@@ -0,0 +1,152 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// noMinifyResolution marks the resolutions the no-minify plugin asks
+// esbuild for, which it leaves alone.
+type noMinifyResolution struct{}
+
+// checkNoMinifyGlobs validates the -no-minify-glob patterns against the
+// options they are to be used with.
+func checkNoMinifyGlobs(globs []string, format api.Format,
+	publicPath string) error {
+	if len(globs) == 0 {
+		return nil
+	}
+	if format != api.FormatESModule {
+		return errors.New("-no-minify-glob needs esm format, the modules " +
+			"it matches are imported at runtime")
+	}
+	if publicPath == "" {
+		return errors.New("-no-minify-glob needs -public-path to import " +
+			"the modules it matches from")
+	}
+	for _, glob := range globs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid -no-minify-glob '%s': %s",
+				glob, err.Error())
+		}
+	}
+	return nil
+}
+
+// matchesAnyGlob reports whether the slash separated path rel matches one
+// of globs.
+func matchesAnyGlob(rel string, globs []string) bool {
+	for _, glob := range globs {
+		if ok, _ := path.Match(glob, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// getNoMinifyPlugin leaves the modules whose paths relative to the working
+// dir, i.e. -in-dir, match one of globs out of the bundle and writes them to
+// outDir unminified.
+//
+// esbuild minifies a bundle as a whole, so the only way to spare a module
+// is to leave it out: the matching modules are made external, imported by
+// their URL under publicPath, and copied to the same path under outDir as
+// they are. So they have to be plain JavaScript the target supports, and
+// their own imports are not bundled, which suits modules that import
+// nothing, such as those building code from templates with new Function.
+// The copies are tracked in written.
+func getNoMinifyPlugin(outDir, publicPath string, globs []string,
+	written *writtenFiles) api.Plugin {
+	return api.Plugin{
+		Name: "NoMinify",
+		Setup: func(build api.PluginBuild) {
+			inDir := getWorkingDir(build.InitialOptions)
+			var mu sync.Mutex
+			// paths relative to inDir, by absolute path
+			matched := make(map[string]string)
+
+			build.OnResolve(api.OnResolveOptions{Filter: `.*`},
+				func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+					// the resolutions other plugins ask for are theirs
+					if args.PluginData != nil {
+						return api.OnResolveResult{}, nil
+					}
+					if args.Kind == api.ResolveEntryPoint {
+						return api.OnResolveResult{}, nil
+					}
+					resolved := build.Resolve(args.Path, api.ResolveOptions{
+						Importer:   args.Importer,
+						Namespace:  args.Namespace,
+						ResolveDir: args.ResolveDir,
+						Kind:       args.Kind,
+						PluginData: noMinifyResolution{},
+						With:       args.With,
+					})
+					if len(resolved.Errors) > 0 || resolved.External ||
+						resolved.Namespace != "file" {
+						return api.OnResolveResult{}, nil
+					}
+					rel, err := filepath.Rel(inDir, resolved.Path)
+					if err != nil || !filepath.IsLocal(rel) {
+						return api.OnResolveResult{}, nil
+					}
+					rel = filepath.ToSlash(rel)
+					if !matchesAnyGlob(rel, globs) {
+						return api.OnResolveResult{}, nil
+					}
+
+					mu.Lock()
+					matched[resolved.Path] = rel
+					mu.Unlock()
+					return api.OnResolveResult{
+						Path: strings.TrimSuffix(publicPath, "/") + "/" +
+							rel,
+						External: true,
+					}, nil
+				})
+
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				if len(result.Errors) > 0 || !build.InitialOptions.Write {
+					return api.OnEndResult{}, nil
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				for abs, rel := range matched {
+					dst := filepath.Join(outDir, filepath.FromSlash(rel))
+					if err := copyModule(abs, dst); err != nil {
+						return api.OnEndResult{}, err
+					}
+					written.copy(dst)
+				}
+				return api.OnEndResult{}, nil
+			})
+		},
+	}
+}
+
+// copyModule copies the module at src to dst.
+func copyModule(src, dst string) error {
+	contents, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, contents, 0644)
+}
@@ -0,0 +1,55 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNoMinifyGlob(t *testing.T) {
+	template := `export function render(name) {
+  return new Function("name", "return '  Hello,   ' + name;")(name);
+}
+`
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/legacy/template.js": template,
+		"ui/app/main.js": `import { render } from "./legacy/template.js";
+			console.info(render("world"));`,
+	})
+	cfg.PublicPath = "/ui"
+	cfg.NoMinifyGlobs = []string{"ui/app/legacy/*.js"}
+
+	result := mustBuild(t, cfg)
+
+	copied := filepath.Join(cfg.OutDir, "ui", "app", "legacy", "template.js")
+	if contents := readFile(t, copied); contents != template {
+		t.Errorf("the matched module is changed:\n%s", contents)
+	}
+	main := output(t, result, cfg.OutDir, "main.js")
+	if !strings.Contains(main, `"/ui/ui/app/legacy/template.js"`) {
+		t.Errorf("the matched module is not imported by its URL:\n%s", main)
+	}
+	if strings.Contains(main, "Hello") {
+		t.Errorf("the matched module is bundled:\n%s", main)
+	}
+}
+
+func TestNoMinifyGlobNeedsPublicPath(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("main");`,
+	})
+	cfg.NoMinifyGlobs = []string{"ui/app/legacy/*.js"}
+
+	if _, err := Run(cfg); err == nil ||
+		!strings.Contains(err.Error(), "needs -public-path") {
+		t.Errorf("expected -public-path to be required, got %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+
+//go:build jsbuild_panic_hook
+
+// Built with the jsbuild_panic_hook tag, the import map plugin panics on
+// PanicSpecifier, so that the tests can check how the tools report a crash.
+
+package jsbuild
+
+// PanicSpecifier is the specifier the import map plugin panics on with the
+// jsbuild_panic_hook build tag.
+const PanicSpecifier = "jsbuild-panic"
+
+func init() {
+	resolveHook = func(specifier string) {
+		if specifier == PanicSpecifier {
+			panic("the jsbuild_panic_hook tag")
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"fmt"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// PanicHint is appended to the messages of panics, which are bugs.
+const PanicHint = "this is a bug, please report it along with the " +
+	"inputs that trigger it"
+
+// esbuild appends the name of the plugin to the error
+func pluginPanicError(r any) error {
+	return fmt.Errorf("panic: %v (%s)", r, PanicHint)
+}
+
+// guardPlugin makes every callback of plugin report a panic as an error of
+// the build. The callbacks run on goroutines of esbuild, so a panic there
+// would otherwise take down the whole process.
+func guardPlugin(plugin api.Plugin) api.Plugin {
+	setup := plugin.Setup
+
+	plugin.Setup = func(build api.PluginBuild) {
+		onStart, onEnd := build.OnStart, build.OnEnd
+		onResolve, onLoad := build.OnResolve, build.OnLoad
+
+		build.OnStart = func(callback func() (api.OnStartResult, error)) {
+			onStart(func() (result api.OnStartResult, err error) {
+				defer func() {
+					if r := recover(); r != nil {
+						err = pluginPanicError(r)
+					}
+				}()
+				return callback()
+			})
+		}
+		build.OnEnd = func(callback func(*api.BuildResult) (api.OnEndResult,
+			error)) {
+			onEnd(func(br *api.BuildResult) (result api.OnEndResult,
+				err error) {
+				defer func() {
+					if r := recover(); r != nil {
+						err = pluginPanicError(r)
+					}
+				}()
+				return callback(br)
+			})
+		}
+		build.OnResolve = func(options api.OnResolveOptions,
+			callback func(api.OnResolveArgs) (api.OnResolveResult, error)) {
+			onResolve(options, func(args api.OnResolveArgs) (
+				result api.OnResolveResult, err error) {
+				defer func() {
+					if r := recover(); r != nil {
+						err = pluginPanicError(r)
+					}
+				}()
+				return callback(args)
+			})
+		}
+		build.OnLoad = func(options api.OnLoadOptions,
+			callback func(api.OnLoadArgs) (api.OnLoadResult, error)) {
+			onLoad(options, func(args api.OnLoadArgs) (
+				result api.OnLoadResult, err error) {
+				defer func() {
+					if r := recover(); r != nil {
+						err = pluginPanicError(r)
+					}
+				}()
+				return callback(args)
+			})
+		}
+
+		setup(build)
+	}
+	return plugin
+}
@@ -0,0 +1,68 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+func TestGuardPlugin(t *testing.T) {
+	var panicky map[string]string
+	plugin := guardPlugin(api.Plugin{
+		Name: "Panicky",
+		Setup: func(build api.PluginBuild) {
+			build.OnResolve(api.OnResolveOptions{Filter: `^lib$`},
+				func(args api.OnResolveArgs) (api.OnResolveResult, error) {
+					panicky[args.Path] = args.Importer
+					return api.OnResolveResult{}, nil
+				})
+		},
+	})
+
+	result := api.Build(api.BuildOptions{
+		Stdin:    &api.StdinOptions{Contents: `import "lib";`},
+		Bundle:   true,
+		Plugins:  []api.Plugin{plugin},
+		LogLevel: api.LogLevelSilent,
+	})
+
+	expected := "panic: assignment to entry in nil map (" + PanicHint + ")"
+	if len(result.Errors) != 1 ||
+		!strings.HasSuffix(result.Errors[0].Text, expected) {
+		t.Errorf("expected the error %q, got %v", expected,
+			messageTexts(result.Errors))
+	}
+}
+
+func TestImportMapPluginPanic(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `import "crash";`,
+	})
+	resolveHook = func(specifier string) {
+		if specifier == "crash" {
+			panic("crafted input")
+		}
+	}
+	t.Cleanup(func() { resolveHook = nil })
+
+	result, err := Run(cfg)
+
+	if err != nil {
+		t.Fatalf("expected the panic to fail the build, got %v", err)
+	}
+	expected := "panic: crafted input (" + PanicHint + ")"
+	if len(result.Errors) != 1 || result.Errors[0].Text != expected ||
+		result.Errors[0].PluginName != "ImportMap" {
+		t.Errorf("expected the error %q of the ImportMap plugin, got %+v",
+			expected, result.Errors)
+	}
+}
@@ -0,0 +1,81 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// stdin can only be read once, but what is read from it may be used by
+// several builds, like the import map or the source of the -dual-format
+// builds
+var stdinInput struct {
+	once sync.Once
+	data []byte
+	err  error
+}
+
+// readStdin returns all of stdin, reading it on the first call.
+func readStdin() ([]byte, error) {
+	stdinInput.once.Do(func() {
+		stdinInput.data, stdinInput.err = io.ReadAll(os.Stdin)
+	})
+	return stdinInput.data, stdinInput.err
+}
+
+// setStdinOptions makes opts build the source read from stdin instead of
+// the entry points. Without an output dir the result is kept in memory, to
+// be written to stdout, so everything that needs an output path is turned
+// off.
+func setStdinOptions(cfg Options, opts *api.BuildOptions) error {
+	var loader api.Loader
+	switch cfg.StdinLoader {
+	case "js":
+		loader = api.LoaderJS
+	case "ts":
+		loader = api.LoaderTS
+	case "jsx":
+		loader = api.LoaderJSX
+	default:
+		return fmt.Errorf("unknown stdin loader '%s', valid values are: "+
+			"js, ts, jsx", cfg.StdinLoader)
+	}
+
+	contents, err := readStdin()
+	if err != nil {
+		return fmt.Errorf("cannot read stdin: %s", err.Error())
+	}
+
+	resolveDir := cfg.StdinResolveDir
+	if resolveDir == "" {
+		resolveDir = cfg.InDir
+	}
+
+	opts.EntryPointsAdvanced = nil
+	opts.Stdin = &api.StdinOptions{
+		Contents:   string(contents),
+		ResolveDir: resolveDir,
+		Sourcefile: "<stdin>",
+		Loader:     loader,
+	}
+
+	if cfg.OutDir == "" {
+		opts.Write = false
+		opts.Splitting = false
+		if opts.Sourcemap != api.SourceMapInline {
+			opts.Sourcemap = api.SourceMapNone
+		}
+	}
+	return nil
+}
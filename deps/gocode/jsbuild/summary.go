@@ -0,0 +1,95 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// FormatSize formats a file size for people to read.
+func FormatSize(bytes int) string {
+	switch {
+	case bytes < 1024:
+		return fmt.Sprintf("%d B", bytes)
+	case bytes < 1024*1024:
+		return fmt.Sprintf("%.1f KiB", float64(bytes)/1024)
+	default:
+		return fmt.Sprintf("%.1f MiB", float64(bytes)/(1024*1024))
+	}
+}
+
+// outputKind tells what an output file of a build is: the bundle of an
+// entry point, a chunk shared by entry points, a source map or an asset.
+func outputKind(path string, output metafileOutput) string {
+	switch {
+	case strings.HasSuffix(path, ".map"):
+		return "map"
+	case output.EntryPoint != "":
+		return "entry"
+	case isJSOutput(path):
+		return "chunk"
+	default:
+		return "asset"
+	}
+}
+
+// getSummaryPlugin logs a table of the outputs of every successful build,
+// with their paths relative to outDir, sizes and kinds.
+func getSummaryPlugin(outDir string) api.Plugin {
+	return api.Plugin{
+		Name: "Summary",
+		Setup: func(build api.PluginBuild) {
+			workingDir := getWorkingDir(build.InitialOptions)
+
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				if len(result.Errors) > 0 {
+					return api.OnEndResult{}, nil
+				}
+
+				meta, err := parseMetafile(result.Metafile)
+				if err != nil {
+					return api.OnEndResult{}, err
+				}
+				outputs := make(map[string]metafileOutput)
+				for path, output := range meta.Outputs {
+					outputs[filepath.Join(workingDir, path)] = output
+				}
+
+				files := append([]api.OutputFile(nil), result.OutputFiles...)
+				sort.Slice(files, func(i, j int) bool {
+					return files[i].Path < files[j].Path
+				})
+
+				names := make([]string, len(files))
+				width := len("Output")
+				for i, file := range files {
+					names[i] = file.Path
+					if rel, err := filepath.Rel(outDir, file.Path); err == nil {
+						names[i] = filepath.ToSlash(rel)
+					}
+					width = max(width, len(names[i]))
+				}
+
+				log.Printf("%-*s  %10s  %s\n", width, "Output", "Size", "Kind")
+				for i, file := range files {
+					log.Printf("%-*s  %10s  %s\n", width, names[i],
+						FormatSize(len(file.Contents)),
+						outputKind(file.Path, outputs[file.Path]))
+				}
+				return api.OnEndResult{}, nil
+			})
+		},
+	}
+}
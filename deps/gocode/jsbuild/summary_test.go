@@ -0,0 +1,53 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	cfg := newTestTree(t, sharedCodeTree)
+	cfg.EntryPoints = []string{"ui/app/main.js", "ui/app/admin.js"}
+	cfg.Sourcemap = "linked"
+	cfg.Summary = true
+	logged := captureLog(t)
+
+	result := mustBuild(t, cfg)
+
+	main := output(t, result, cfg.OutDir, "main.js")
+	summary := logged.String()
+	for _, re := range []string{
+		`(?m)^Output +Size  Kind$`,
+		`(?m)^main\.js +` + regexp.QuoteMeta(FormatSize(len(main))) +
+			`  entry$`,
+		`(?m)^main\.js\.map +\d+ B  map$`,
+		`(?m)^chunk-\w+\.js +\d+ B  chunk$`,
+	} {
+		if !regexp.MustCompile(re).MatchString(summary) {
+			t.Errorf("%s doesn't match the summary:\n%s", re, summary)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for bytes, expected := range map[int]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1024:            "1.0 KiB",
+		1536:            "1.5 KiB",
+		3 * 1024 * 1024: "3.0 MiB",
+	} {
+		if size := FormatSize(bytes); size != expected {
+			t.Errorf("FormatSize(%d) = %q, expected %q", bytes, size,
+				expected)
+		}
+	}
+}
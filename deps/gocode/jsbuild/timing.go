@@ -0,0 +1,75 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+const timingTopInputs = 10
+
+// logLargestInputs logs the timingTopInputs largest inputs of the build
+// described by meta.
+func logLargestInputs(meta metafile, workingDir, inDir string) {
+	paths := make([]string, 0, len(meta.Inputs))
+	for path := range meta.Inputs {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		bi, bj := meta.Inputs[paths[i]].Bytes, meta.Inputs[paths[j]].Bytes
+		if bi != bj {
+			return bi > bj
+		}
+		return paths[i] < paths[j]
+	})
+	if len(paths) > timingTopInputs {
+		paths = paths[:timingTopInputs]
+	}
+
+	log.Printf("Largest inputs:\n")
+	for _, path := range paths {
+		log.Printf("  %10d  %s\n", meta.Inputs[path].Bytes,
+			relMetafilePath(workingDir, path, inDir))
+	}
+}
+
+// getTimingPlugin logs how long every build takes, from its start until
+// esbuild is done and before the post-build plugins run, and, if
+// largestInputs is set, the largest inputs of the build.
+func getTimingPlugin(inDir string, largestInputs bool) api.Plugin {
+	var start time.Time
+
+	return api.Plugin{
+		Name: "Timing",
+		Setup: func(build api.PluginBuild) {
+			workingDir := getWorkingDir(build.InitialOptions)
+
+			build.OnStart(func() (api.OnStartResult, error) {
+				start = time.Now()
+				return api.OnStartResult{}, nil
+			})
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				log.Printf("Build took %v\n", time.Since(start))
+				if !largestInputs || len(result.Errors) > 0 {
+					return api.OnEndResult{}, nil
+				}
+				meta, err := parseMetafile(result.Metafile)
+				if err != nil {
+					return api.OnEndResult{}, err
+				}
+				logLargestInputs(meta, workingDir, inDir)
+				return api.OnEndResult{}, nil
+			})
+		},
+	}
+}
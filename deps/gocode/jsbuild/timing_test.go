@@ -0,0 +1,48 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTiming(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/lib.js": `export const lib = "` +
+			strings.Repeat("x", 100) + `";`,
+		"ui/app/main.js": `import { lib } from "./lib.js";
+			console.info(lib);`,
+	})
+	cfg.Timing = true
+
+	logged := captureLog(t)
+	mustBuild(t, cfg)
+
+	timing := regexp.MustCompile(`(?m)^Build took \d+(\.\d+)?(µs|ms|s)$`)
+	if !timing.MatchString(logged.String()) {
+		t.Errorf("no timing line is logged:\n%s", logged)
+	}
+	if strings.Contains(logged.String(), "Largest inputs") {
+		t.Errorf("the largest inputs are logged without -metafile:\n%s",
+			logged)
+	}
+
+	cfg.Metafile = filepath.Join(cfg.OutDir, "meta.json")
+	logged = captureLog(t)
+	mustBuild(t, cfg)
+
+	largest := regexp.MustCompile(`Largest inputs:\n +\d+  ui/app/lib.js\n` +
+		` +\d+  ui/app/main.js\n`)
+	if !largest.MatchString(logged.String()) {
+		t.Errorf("the largest inputs are not logged in order:\n%s", logged)
+	}
+}
@@ -0,0 +1,205 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// transformLoader returns the loader to transform the file at path with:
+// the one given for its extension, or the one esbuild would use.
+func transformLoader(path string, loaders map[string]api.Loader) api.Loader {
+	ext := filepath.Ext(path)
+	if loader, ok := loaders[ext]; ok {
+		return loader
+	}
+	switch ext {
+	case ".css":
+		return api.LoaderCSS
+	case ".json":
+		return api.LoaderJSON
+	case ".ts", ".mts", ".cts":
+		return api.LoaderTS
+	}
+	return api.LoaderJS
+}
+
+// transformOptions converts the options of a build to those transforming
+// a single file the same way.
+func transformOptions(opts api.BuildOptions) api.TransformOptions {
+	topts := api.TransformOptions{
+		Target:            opts.Target,
+		Engines:           opts.Engines,
+		Platform:          opts.Platform,
+		Format:            opts.Format,
+		GlobalName:        opts.GlobalName,
+		SourceRoot:        opts.SourceRoot,
+		Drop:              opts.Drop,
+		MangleProps:       opts.MangleProps,
+		ReserveProps:      opts.ReserveProps,
+		MinifyWhitespace:  opts.MinifyWhitespace,
+		MinifyIdentifiers: opts.MinifyIdentifiers,
+		MinifySyntax:      opts.MinifySyntax,
+		Charset:           opts.Charset,
+		TreeShaking:       opts.TreeShaking,
+		IgnoreAnnotations: opts.IgnoreAnnotations,
+		LegalComments:     opts.LegalComments,
+		JSX:               opts.JSX,
+		JSXFactory:        opts.JSXFactory,
+		JSXFragment:       opts.JSXFragment,
+		Define:            opts.Define,
+		Pure:              opts.Pure,
+		KeepNames:         opts.KeepNames,
+		Banner:            opts.Banner["js"],
+		Footer:            opts.Footer["js"],
+		LogLevel:          opts.LogLevel,
+		Color:             opts.Color,
+		LogLimit:          opts.LogLimit,
+	}
+	switch opts.Sourcemap {
+	case api.SourceMapLinked, api.SourceMapExternal:
+		// the link, if any, is added once the map file is named
+		topts.Sourcemap = api.SourceMapExternal
+	default:
+		topts.Sourcemap = opts.Sourcemap
+	}
+	return topts
+}
+
+// checkTransformOnly rejects the options that only apply to bundling and
+// would be silently ignored by -transform.
+func checkTransformOnly(cfg Options) error {
+	bundling := []struct {
+		flag string
+		set  bool
+	}{
+		{"-dual-format", cfg.DualFormat},
+		{"-metafile", cfg.Metafile != ""},
+		{"-graph-out", cfg.GraphOut != ""},
+		{"-write-manifest", cfg.ManifestPath != ""},
+		{"-write-map-dir", cfg.MapDir != ""},
+		{"-integrity", cfg.Integrity},
+		{"-compress", cfg.Compress != ""},
+		{"-post-build", cfg.PostBuild != ""},
+		{"-cache-dir", cfg.CacheDir != ""},
+		{"-archive", cfg.Archive != ""},
+		// the import maps are dropped, as nothing is resolved
+		{"-verify-importmap", cfg.VerifyImportMap},
+	}
+	for _, opt := range bundling {
+		if opt.set {
+			return fmt.Errorf("-transform can't be used with %s", opt.flag)
+		}
+	}
+	return nil
+}
+
+// runTransform transforms the single entry point of cfg, or stdin, with
+// esbuild's transform API: its imports are left as they are. The output
+// is kept in memory without an output dir.
+func runTransform(cfg Options) (Result, error) {
+	if err := checkTransformOnly(cfg); err != nil {
+		return Result{}, &OptionsError{err}
+	}
+	// nothing is resolved
+	cfg.ResolveMode = ResolveModeNodePaths
+	cfg.ImportMapPaths = nil
+	opts, err := prepare(&cfg)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var contents, sourcefile string
+	var loader api.Loader
+	if opts.Stdin != nil {
+		contents = opts.Stdin.Contents
+		sourcefile = opts.Stdin.Sourcefile
+		loader = opts.Stdin.Loader
+	} else {
+		if len(opts.EntryPointsAdvanced) != 1 {
+			return Result{}, &OptionsError{errors.New("-transform needs " +
+				"exactly one entry point")}
+		}
+		sourcefile = opts.EntryPointsAdvanced[0].InputPath
+		data, err := os.ReadFile(sourcefile)
+		if err != nil {
+			return Result{}, err
+		}
+		contents = string(data)
+		loader = transformLoader(sourcefile, opts.Loader)
+	}
+
+	topts := transformOptions(opts)
+	topts.Loader = loader
+	topts.Sourcefile = sourcefile
+	if cfg.OutDir == "" && topts.Sourcemap == api.SourceMapExternal {
+		// only the code goes to stdout
+		topts.Sourcemap = api.SourceMapNone
+	}
+	transformed := api.Transform(contents, topts)
+	result := Result{
+		Errors:   transformed.Errors,
+		Warnings: transformed.Warnings,
+	}
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	ext := ".js"
+	if loader == api.LoaderCSS {
+		ext = ".css"
+	}
+	outExt := ext
+	if custom, ok := opts.OutExtension[ext]; ok {
+		outExt = custom
+	}
+	base := strings.TrimSuffix(filepath.Base(sourcefile),
+		filepath.Ext(sourcefile))
+	if opts.Stdin != nil {
+		base = "stdin"
+	}
+	path := filepath.Join(cfg.OutDir, base+outExt)
+
+	code := transformed.Code
+	if len(transformed.Map) > 0 {
+		if opts.Sourcemap == api.SourceMapLinked {
+			comment := "//# sourceMappingURL=%s\n"
+			if ext == ".css" {
+				comment = "/*# sourceMappingURL=%s */\n"
+			}
+			code = append(code, fmt.Sprintf(comment,
+				filepath.Base(path)+".map")...)
+		}
+		result.OutputFiles = append(result.OutputFiles, api.OutputFile{
+			Path:     path + ".map",
+			Contents: transformed.Map,
+		})
+	}
+	result.OutputFiles = append([]api.OutputFile{{
+		Path:     path,
+		Contents: code,
+	}}, result.OutputFiles...)
+
+	if cfg.OutDir != "" && !cfg.DryRun {
+		for _, file := range result.OutputFiles {
+			if err := os.WriteFile(file.Path, file.Contents, 0644); err != nil {
+				return Result{}, err
+			}
+		}
+	}
+
+	result.OverBudget = !fitsSizeBudget(cfg, result.OutputFiles)
+	return result, nil
+}
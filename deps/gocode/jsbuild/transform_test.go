@@ -0,0 +1,79 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"strings"
+	"testing"
+)
+
+// transformES2020 transforms a main.js using ES2020 syntax for target and
+// returns the output.
+func transformES2020(t *testing.T, target string) string {
+	t.Helper()
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/lib.js": `export const lib = {};`,
+		"ui/app/main.js": `import { lib } from "./lib.js";
+			console.info(lib?.name ?? "unnamed");`,
+	})
+	cfg.TransformOnly = true
+	cfg.OutDir = ""
+	cfg.Target = target
+
+	result := mustBuild(t, cfg)
+
+	if len(result.OutputFiles) != 1 {
+		t.Fatalf("expected a single output, got %d", len(result.OutputFiles))
+	}
+	main := output(t, result, "", "main.js")
+	if !strings.Contains(main, `from"./lib.js"`) {
+		t.Errorf("the import isn't left as it is:\n%s", main)
+	}
+	return main
+}
+
+func TestTransformLowersSyntax(t *testing.T) {
+	main := transformES2020(t, "chrome79")
+
+	for _, syntax := range []string{"?.", "??"} {
+		if strings.Contains(main, syntax) {
+			t.Errorf("%s isn't lowered for chrome79:\n%s", syntax, main)
+		}
+	}
+}
+
+func TestTransformKeepsSupportedSyntax(t *testing.T) {
+	main := transformES2020(t, "chrome100")
+
+	for _, syntax := range []string{"?.", "??"} {
+		if !strings.Contains(main, syntax) {
+			t.Errorf("%s is lowered for chrome100:\n%s", syntax, main)
+		}
+	}
+}
+
+func TestTransformRejectsBundlingOptions(t *testing.T) {
+	for flag, set := range map[string]func(*Options){
+		"-integrity":        func(cfg *Options) { cfg.Integrity = true },
+		"-verify-importmap": func(cfg *Options) { cfg.VerifyImportMap = true },
+	} {
+		cfg := newTestTree(t, map[string]string{
+			"ui/app/main.js": `console.info("main");`,
+		})
+		cfg.TransformOnly = true
+		set(&cfg)
+
+		_, err := Run(cfg)
+
+		expected := "-transform can't be used with " + flag
+		if err == nil || err.Error() != expected {
+			t.Errorf("expected %q, got %v", expected, err)
+		}
+	}
+}
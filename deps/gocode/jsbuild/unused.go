@@ -0,0 +1,66 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"log"
+	"sort"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// getUnusedReportPlugin logs the modules that are part of the module graph
+// but have nothing left in any of the outputs after tree shaking, with
+// paths relative to inDir. Such modules are candidates for removal.
+func getUnusedReportPlugin(inDir string) api.Plugin {
+	return api.Plugin{
+		Name: "UnusedReport",
+		Setup: func(build api.PluginBuild) {
+			workingDir := getWorkingDir(build.InitialOptions)
+
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				if len(result.Errors) > 0 {
+					return api.OnEndResult{}, nil
+				}
+
+				meta, err := parseMetafile(result.Metafile)
+				if err != nil {
+					return api.OnEndResult{}, err
+				}
+
+				used := make(map[string]bool)
+				for _, output := range meta.Outputs {
+					for path, input := range output.Inputs {
+						if input.BytesInOutput > 0 {
+							used[path] = true
+						}
+					}
+				}
+
+				var unused []string
+				for path := range meta.Inputs {
+					if !used[path] {
+						unused = append(unused,
+							relMetafilePath(workingDir, path, inDir))
+					}
+				}
+				if len(unused) == 0 {
+					return api.OnEndResult{}, nil
+				}
+
+				sort.Strings(unused)
+				log.Printf("Modules with no code in the output:\n")
+				for _, path := range unused {
+					log.Printf("  %s\n", path)
+				}
+				return api.OnEndResult{}, nil
+			})
+		},
+	}
+}
@@ -0,0 +1,37 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReportUnused(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/used.js":   `export const used = "used";`,
+		"ui/app/unused.js": `export const unused = "unused";`,
+		"ui/app/main.js": `import { used } from "./used.js";
+			import { unused } from "./unused.js";
+			console.info(used);`,
+	})
+	cfg.ReportUnused = true
+	logged := captureLog(t)
+
+	mustBuild(t, cfg)
+
+	expected := "Modules with no code in the output:\n  ui/app/unused.js\n"
+	if report := logged.String(); !strings.Contains(report, expected) {
+		t.Errorf("expected %q in the report:\n%s", expected, report)
+	} else if strings.Contains(report, "ui/app/used.js") ||
+		strings.Contains(report, "ui/app/main.js") {
+		t.Errorf("modules with code in the output are reported:\n%s",
+			report)
+	}
+}
@@ -0,0 +1,29 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// getWarningsAsErrorsPlugin turns the warnings of a build into errors, so
+// that the build fails. It has to come before the plugins that skip failed
+// builds.
+func getWarningsAsErrorsPlugin() api.Plugin {
+	return api.Plugin{
+		Name: "WarningsAsErrors",
+		Setup: func(build api.PluginBuild) {
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				result.Errors = append(result.Errors, result.Warnings...)
+				result.Warnings = nil
+				return api.OnEndResult{}, nil
+			})
+		},
+	}
+}
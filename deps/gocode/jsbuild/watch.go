@@ -0,0 +1,263 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// WatchOptions configures Watch.
+type WatchOptions struct {
+	// Serve makes Watch also serve the output dir over HTTP, on ServePort,
+	// or on the first free port from 8000 if that is 0.
+	Serve     bool
+	ServePort int
+	// Debounce is how long to wait after a change before rebuilding, so
+	// that a burst of changes, e.g. from saving all files in an editor,
+	// only triggers one rebuild.
+	Debounce time.Duration
+	// Granular gives every entry point a build of its own, so that a
+	// change only rebuilds the entry points that depend on it.
+	Granular bool
+	// Ignore lists glob patterns, relative to the in dir, of files whose
+	// changes don't trigger rebuilds. A "**" element in them matches any
+	// number of path elements. esbuild can't be told to leave files
+	// alone, so the inputs are polled for changes instead then.
+	Ignore []string
+	// Stop ends watching once it is closed.
+	Stop <-chan struct{}
+}
+
+func getRebuildLoggerPlugin(report func(Result)) api.Plugin {
+	var start time.Time
+
+	return api.Plugin{
+		Name: "RebuildLogger",
+		Setup: func(build api.PluginBuild) {
+			build.OnStart(func() (api.OnStartResult, error) {
+				start = time.Now()
+				return api.OnStartResult{}, nil
+			})
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				log.Printf("Rebuild finished with %d error(s) in %v\n",
+					len(result.Errors), time.Since(start))
+				report(newResult(*result))
+				return api.OnEndResult{}, nil
+			})
+		},
+	}
+}
+
+// commonDir returns the deepest dir that contains all of paths.
+func commonDir(paths []string) string {
+	dir := filepath.Dir(paths[0])
+	for _, path := range paths[1:] {
+		for {
+			rel, err := filepath.Rel(dir, path)
+			if err == nil && !strings.HasPrefix(rel, "..") {
+				break
+			}
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				return dir
+			}
+			dir = parent
+		}
+	}
+	return dir
+}
+
+// splitEntryPoints returns the options of one build per entry point of
+// opts, which were built from cfg. The outputs go where the whole build
+// would put them, but without chunks shared by the entry points. A file
+// that describes all of the entry points can't be written by one of them,
+// so opts are kept whole if cfg asks for such a file.
+func splitEntryPoints(cfg Options,
+	opts api.BuildOptions) ([]api.BuildOptions, error) {
+	if len(opts.EntryPointsAdvanced) < 2 {
+		return []api.BuildOptions{opts}, nil
+	}
+
+	var shared string
+	switch {
+	case getManifestPath(cfg) != "":
+		shared = "the manifest"
+	case cfg.Integrity:
+		shared = IntegrityFileName
+	case cfg.Metafile != "":
+		shared = "the -metafile"
+	case cfg.GraphOut != "":
+		shared = "the -graph-out graph"
+	case cfg.MaxSize != "":
+		shared = "the -max-size budget"
+	}
+	if shared != "" {
+		logWarning(cfg.Quiet, "Warning: every change rebuilds all entry "+
+			"points, as %s covers all of them\n", shared)
+		return []api.BuildOptions{opts}, nil
+	}
+
+	if opts.Splitting {
+		logWarning(cfg.Quiet, "Warning: code splitting is disabled for "+
+			"-watch-granular, every entry point is bundled on its own\n")
+	}
+
+	var paths []string
+	for _, entry := range opts.EntryPointsAdvanced {
+		paths = append(paths, entry.InputPath)
+	}
+	outbase := commonDir(paths)
+
+	var builds []api.BuildOptions
+	for _, entry := range opts.EntryPointsAdvanced {
+		// fresh plugins for every build, they keep state
+		entryOpts, err := buildOptions(cfg)
+		if err != nil {
+			return nil, err
+		}
+		entryOpts.EntryPointsAdvanced = []api.EntryPoint{entry}
+		entryOpts.Outbase = outbase
+		entryOpts.Splitting = false
+		builds = append(builds, entryOpts)
+	}
+	return builds, nil
+}
+
+// Watch builds cfg and keeps rebuilding it whenever one of the inputs
+// changes, until watchOpts.Stop is closed. The result of every build is
+// passed to report. An *OptionsError is returned if cfg is invalid, any
+// other error if watching can't start.
+func Watch(cfg Options, watchOpts WatchOptions, report func(Result)) error {
+	switch {
+	case cfg.CacheDir != "":
+		return &OptionsError{errors.New("-watch and -serve can't be used " +
+			"with -cache-dir, rebuilds are incremental already")}
+	case cfg.DryRun:
+		return &OptionsError{errors.New("-watch and -serve can't be used " +
+			"with -dry-run")}
+	case cfg.Archive != "":
+		return &OptionsError{errors.New("-watch and -serve can't be used " +
+			"with -archive, which is only written by a single build")}
+	case cfg.Stdin:
+		return &OptionsError{errors.New("-watch and -serve can't be used " +
+			"with -stdin")}
+	case cfg.DualFormat:
+		return &OptionsError{errors.New("-watch and -serve can't be used " +
+			"with -dual-format")}
+	case cfg.TransformOnly:
+		return &OptionsError{errors.New("-watch and -serve can't be used " +
+			"with -transform")}
+	case watchOpts.Debounce < 0:
+		return &OptionsError{errors.New("-watch-debounce can't be " +
+			"negative")}
+	}
+	if err := checkWatchIgnores(watchOpts.Ignore); err != nil {
+		return &OptionsError{err}
+	}
+
+	opts, err := prepare(&cfg)
+	if err != nil {
+		return err
+	}
+
+	builds := []api.BuildOptions{opts}
+	if watchOpts.Granular {
+		builds, err = splitEntryPoints(cfg, opts)
+		if err != nil {
+			return &OptionsError{err}
+		}
+	}
+
+	// the same checks as after the build in Run
+	checkedReport := func(result Result) {
+		if len(result.Errors) == 0 {
+			result.OverBudget = !fitsSizeBudget(cfg, result.OutputFiles)
+		}
+		report(result)
+	}
+
+	// closed on return, which stops the pollers even if a later build
+	// fails to start watching
+	stopPolling := make(chan struct{})
+	var pollers sync.WaitGroup
+	var contexts []api.BuildContext
+	defer func() {
+		close(stopPolling)
+		// a poller may still be rebuilding its context
+		pollers.Wait()
+		for _, ctx := range contexts {
+			ctx.Dispose()
+		}
+	}()
+	for _, build := range builds {
+		var set *watchSet
+		if len(watchOpts.Ignore) > 0 {
+			set = &watchSet{globs: watchOpts.Ignore,
+				extra: importMapFiles(cfg.ImportMapPaths)}
+			build.Metafile = true
+			build.Plugins = append(build.Plugins,
+				guardPlugin(getWatchSetPlugin(set)))
+		}
+		build.Plugins = append(build.Plugins,
+			guardPlugin(getRebuildLoggerPlugin(checkedReport)))
+
+		ctx, ctxErr := api.Context(build)
+		if ctxErr != nil {
+			return fmt.Errorf("failed to start watching: %s",
+				ctxErr.Error())
+		}
+		contexts = append(contexts, ctx)
+
+		if set != nil {
+			ctx.Rebuild()
+			pollers.Add(1)
+			go func() {
+				defer pollers.Done()
+				pollWatch(ctx, set, watchOpts.Debounce, stopPolling)
+			}()
+			continue
+		}
+		err := ctx.Watch(api.WatchOptions{
+			Delay: int(watchOpts.Debounce.Milliseconds()),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to start watching: %s", err.Error())
+		}
+	}
+
+	if watchOpts.Serve {
+		// the other builds write to the same dir, which is served as is
+		ctx := contexts[0]
+		serveOpts := api.ServeOptions{
+			Port:     watchOpts.ServePort,
+			Servedir: cfg.OutDir,
+		}
+		serveResult, err := ctx.Serve(serveOpts)
+		if err != nil {
+			return fmt.Errorf("failed to start serving: %s", err.Error())
+		}
+		for _, host := range serveResult.Hosts {
+			log.Printf("Serving %s on http://%s\n", serveOpts.Servedir,
+				net.JoinHostPort(host, strconv.Itoa(int(serveResult.Port))))
+		}
+	}
+
+	<-watchOpts.Stop
+	return nil
+}
@@ -0,0 +1,284 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServe(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("served");`,
+	})
+	logged := captureLog(t)
+
+	results := watchResults(t, cfg, WatchOptions{Serve: true})
+	if result := nextResult(t, results); len(result.Errors) > 0 {
+		t.Fatalf("build failed: %v", messageTexts(result.Errors))
+	}
+
+	serving := regexp.MustCompile(`Serving \S+ on (http://\S+)`)
+	var match []string
+	for deadline := time.Now().Add(10 * time.Second); match == nil; {
+		if time.Now().After(deadline) {
+			t.Fatalf("the server address isn't logged:\n%s", logged)
+		}
+		time.Sleep(10 * time.Millisecond)
+		match = serving.FindStringSubmatch(logged.String())
+	}
+
+	resp, err := http.Get(match[1] + "/main.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "text/javascript") {
+		t.Errorf("expected a JS content type, got %q", contentType)
+	}
+	if !strings.Contains(string(body), `"served"`) {
+		t.Errorf("unexpected main.js:\n%s", body)
+	}
+}
+
+func TestWatchImportMapChange(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/importmap.json":   `{"imports": {"lib": "./web_modules/a.js"}}`,
+		"ui/web_modules/a.js": `export const lib = "first mapping";`,
+		"ui/web_modules/b.js": `export const lib = "second mapping";`,
+		"ui/app/main.js":      `import { lib } from "lib"; console.info(lib);`,
+	})
+	outPath := filepath.Join(cfg.OutDir, "main.js")
+
+	results := watchResults(t, cfg, WatchOptions{})
+
+	if result := nextResult(t, results); len(result.Errors) > 0 {
+		t.Fatalf("build failed: %v", messageTexts(result.Errors))
+	}
+	if out := readFile(t, outPath); !strings.Contains(out, "first mapping") {
+		t.Fatalf("unexpected output of the first build:\n%s", out)
+	}
+
+	writeTree(t, cfg.InDir, map[string]string{
+		"ui/importmap.json": `{"imports": {"lib": "./web_modules/b.js"}}`,
+	})
+	if result := nextResult(t, results); len(result.Errors) > 0 {
+		t.Fatalf("rebuild failed: %v", messageTexts(result.Errors))
+	}
+	if out := readFile(t, outPath); !strings.Contains(out, "second mapping") {
+		t.Errorf("the new mapping is not used:\n%s", out)
+	}
+}
+
+func TestWatchDebounce(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("initial");`,
+	})
+
+	results := watchResults(t, cfg, WatchOptions{Debounce: time.Second})
+	if result := nextResult(t, results); len(result.Errors) > 0 {
+		t.Fatalf("build failed: %v", messageTexts(result.Errors))
+	}
+
+	for _, text := range []string{"first", "second", "third"} {
+		writeTree(t, cfg.InDir, map[string]string{
+			"ui/app/main.js": `console.info("` + text + `");`,
+		})
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if result := nextResult(t, results); len(result.Errors) > 0 {
+		t.Fatalf("rebuild failed: %v", messageTexts(result.Errors))
+	}
+	out := readFile(t, filepath.Join(cfg.OutDir, "main.js"))
+	if !strings.Contains(out, "third") {
+		t.Errorf("the rebuild misses the last change:\n%s", out)
+	}
+	select {
+	case <-results:
+		t.Error("the changes trigger more than one rebuild")
+	case <-time.After(2 * time.Second):
+	}
+}
+
+func TestWatchGranular(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/a.js": `console.info("a");`,
+		"ui/app/b.js": `console.info("b");`,
+	})
+	cfg.EntryPoints = []string{"ui/app/a.js", "ui/app/b.js"}
+	outB := filepath.Join(cfg.OutDir, "b.js")
+
+	results := watchResults(t, cfg, WatchOptions{Granular: true})
+	for i := 0; i < 2; i++ {
+		if result := nextResult(t, results); len(result.Errors) > 0 {
+			t.Fatalf("build failed: %v", messageTexts(result.Errors))
+		}
+	}
+	built, err := os.Stat(outB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeTree(t, cfg.InDir, map[string]string{
+		"ui/app/a.js": `console.info("changed a");`,
+	})
+	if result := nextResult(t, results); len(result.Errors) > 0 {
+		t.Fatalf("rebuild failed: %v", messageTexts(result.Errors))
+	}
+	select {
+	case <-results:
+		t.Error("changing a.js rebuilds b.js too")
+	case <-time.After(time.Second):
+	}
+
+	out := readFile(t, filepath.Join(cfg.OutDir, "a.js"))
+	if !strings.Contains(out, "changed a") {
+		t.Errorf("a.js is not rebuilt:\n%s", out)
+	}
+	if info, err := os.Stat(outB); err != nil ||
+		!info.ModTime().Equal(built.ModTime()) {
+		t.Errorf("b.js is rewritten: %v", err)
+	}
+}
+
+func TestWatchIgnore(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/node_modules/lib/index.js": `export const lib = "vendored";`,
+		"ui/app/main.js": `import { lib } from "../node_modules/lib/index.js";
+			console.info(lib, "initial");`,
+	})
+
+	results := watchResults(t, cfg,
+		WatchOptions{Ignore: []string{"**/node_modules/**"}})
+	if result := nextResult(t, results); len(result.Errors) > 0 {
+		t.Fatalf("build failed: %v", messageTexts(result.Errors))
+	}
+
+	writeTree(t, cfg.InDir, map[string]string{
+		"ui/node_modules/lib/index.js": `export const lib = "touched";`,
+	})
+	select {
+	case <-results:
+		t.Fatal("a change in an ignored file triggers a rebuild")
+	case <-time.After(time.Second):
+	}
+
+	writeTree(t, cfg.InDir, map[string]string{
+		"ui/app/main.js": `import { lib } from "../node_modules/lib/index.js";
+			console.info(lib, "changed");`,
+	})
+	if result := nextResult(t, results); len(result.Errors) > 0 {
+		t.Fatalf("rebuild failed: %v", messageTexts(result.Errors))
+	}
+	out := readFile(t, filepath.Join(cfg.OutDir, "main.js"))
+	if !strings.Contains(out, "changed") {
+		t.Errorf("a change in a watched file isn't rebuilt:\n%s", out)
+	}
+}
+
+func TestWatchIgnoreWaitsForRebuild(t *testing.T) {
+	cfg := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("initial");`,
+	})
+	results := make(chan Result, 16)
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(cfg, WatchOptions{
+			Ignore:   []string{"**/node_modules/**"},
+			Debounce: time.Second,
+			Stop:     stop,
+		}, func(result Result) {
+			results <- result
+		})
+	}()
+	nextResult(t, results)
+
+	writeTree(t, cfg.InDir, map[string]string{
+		"ui/app/main.js": `console.info("changed");`,
+	})
+	// the change is seen, and the rebuild waits for the debounce
+	time.Sleep(2 * watchPollInterval)
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	select {
+	case result := <-results:
+		if len(result.Errors) > 0 {
+			t.Errorf("rebuild failed: %v", messageTexts(result.Errors))
+		}
+	default:
+		t.Error("Watch returns before the pending rebuild is done")
+	}
+}
+
+func TestMatchGlobstar(t *testing.T) {
+	for _, test := range []struct {
+		glob, name string
+		match      bool
+	}{
+		{"**/node_modules/**", "node_modules/lib/index.js", true},
+		{"**/node_modules/**", "ui/node_modules/lib/index.js", true},
+		{"**/node_modules/**", "ui/app/main.js", false},
+		{"ui/*.js", "ui/main.js", true},
+		{"ui/*.js", "ui/app/main.js", false},
+		{"ui/**/*.js", "ui/main.js", true},
+		{"ui/**/*.js", "ui/app/views/main.js", true},
+		{"ui/**/*.js", "ui/app/main.css", false},
+	} {
+		if match := matchGlobstar(test.glob, test.name); match != test.match {
+			t.Errorf("matchGlobstar(%q, %q) = %v, expected %v", test.glob,
+				test.name, match, test.match)
+		}
+	}
+}
+
+func TestGetInputPaths(t *testing.T) {
+	workingDir := t.TempDir()
+	abs := filepath.Join(t.TempDir(), "linked", "lib.js")
+	meta := metafile{Inputs: map[string]metafileInput{
+		"ui/app/main.js":                      {},
+		filepath.Join("..", "shared", "x.js"): {},
+		abs:                                   {},
+		"virtual:features":                    {},
+	}}
+
+	paths := getInputPaths(workingDir, meta)
+
+	sort.Strings(paths)
+	expected := []string{
+		filepath.Join(workingDir, "ui", "app", "main.js"),
+		filepath.Join(filepath.Dir(workingDir), "shared", "x.js"),
+		abs,
+	}
+	sort.Strings(expected)
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("unexpected paths %v, expected %v", paths, expected)
+	}
+}
@@ -0,0 +1,185 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package jsbuild
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evanw/esbuild/pkg/api"
+)
+
+// checkWatchIgnores validates the -watch-ignore patterns.
+func checkWatchIgnores(globs []string) error {
+	for _, glob := range globs {
+		for _, elem := range strings.Split(glob, "/") {
+			if _, err := path.Match(elem, ""); err != nil {
+				return fmt.Errorf("invalid -watch-ignore pattern '%s': %s",
+					glob, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// matchGlobstar reports whether the slash separated path name matches
+// glob, in which a "**" element matches any number of path elements and
+// the other elements are matched as by path.Match.
+func matchGlobstar(glob, name string) bool {
+	return matchElems(strings.Split(glob, "/"), strings.Split(name, "/"))
+}
+
+func matchElems(glob, name []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := len(name); i >= 0; i-- {
+				if matchElems(glob[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(glob[0], name[0]); !ok {
+			return false
+		}
+		glob, name = glob[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// watchPollInterval is how often the inputs are checked for changes when
+// watching them without esbuild.
+const watchPollInterval = 250 * time.Millisecond
+
+// watchSet holds the inputs of the last successful build of a context,
+// with their modification times, minus the ignored ones. esbuild watches
+// every file a build reads, so builds with ignored files are watched by
+// polling these instead.
+type watchSet struct {
+	globs []string
+	extra []string
+
+	mu    sync.Mutex
+	files map[string]time.Time
+}
+
+// update replaces the watched files with paths, the absolute paths of the
+// inputs of a build and the extra files, relative to inDir for matching
+// the globs.
+func (s *watchSet) update(inDir string, paths []string) {
+	files := make(map[string]time.Time)
+	for _, path := range append(paths, s.extra...) {
+		rel, err := filepath.Rel(inDir, path)
+		if err == nil && filepath.IsLocal(rel) {
+			ignored := false
+			for _, glob := range s.globs {
+				ignored = ignored || matchGlobstar(glob, filepath.ToSlash(rel))
+			}
+			if ignored {
+				continue
+			}
+		}
+		var modTime time.Time
+		if info, err := os.Stat(path); err == nil {
+			modTime = info.ModTime()
+		}
+		files[path] = modTime
+	}
+
+	s.mu.Lock()
+	s.files = files
+	s.mu.Unlock()
+}
+
+// changed reports whether any of the watched files has been modified or
+// removed since the last check.
+func (s *watchSet) changed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := false
+	for path, modTime := range s.files {
+		var current time.Time
+		if info, err := os.Stat(path); err == nil {
+			current = info.ModTime()
+		}
+		if !current.Equal(modTime) {
+			s.files[path] = current
+			changed = true
+		}
+	}
+	return changed
+}
+
+// getInputPaths returns the absolute paths of the file inputs in meta,
+// whose paths are relative to workingDir unless they are absolute.
+func getInputPaths(workingDir string, meta metafile) []string {
+	var paths []string
+	for path := range meta.Inputs {
+		switch {
+		case filepath.IsAbs(path):
+			paths = append(paths, path)
+		case strings.Contains(path, ":"):
+			// an input of another namespace, prefixed with it
+		default:
+			paths = append(paths, filepath.Join(workingDir, path))
+		}
+	}
+	return paths
+}
+
+// getWatchSetPlugin updates set with the inputs of every successful build.
+// A failed build leaves it as it is, so that fixing the error in one of the
+// files it had triggers a rebuild.
+func getWatchSetPlugin(set *watchSet) api.Plugin {
+	return api.Plugin{
+		Name: "WatchSet",
+		Setup: func(build api.PluginBuild) {
+			workingDir := getWorkingDir(build.InitialOptions)
+
+			build.OnEnd(func(result *api.BuildResult) (api.OnEndResult, error) {
+				if len(result.Errors) > 0 {
+					return api.OnEndResult{}, nil
+				}
+				meta, err := parseMetafile(result.Metafile)
+				if err != nil {
+					return api.OnEndResult{}, err
+				}
+				set.update(workingDir, getInputPaths(workingDir, meta))
+				return api.OnEndResult{}, nil
+			})
+		},
+	}
+}
+
+// pollWatch rebuilds ctx whenever one of the files in set changes, waiting
+// for debounce first, until stop is closed.
+func pollWatch(ctx api.BuildContext, set *watchSet, debounce time.Duration,
+	stop <-chan struct{}) {
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if set.changed() {
+				time.Sleep(debounce)
+				ctx.Rebuild()
+			}
+		}
+	}
+}
@@ -10,56 +10,82 @@ package main
 
 import (
 	"flag"
-	"os"
+	"fmt"
 	"log"
+	"os"
+
+	"github.com/couchbase/ns_server/deps/gocode/esbuildutils"
 	"github.com/evanw/esbuild/pkg/api"
 )
 
+const (
+	exitBuildFailed = 1
+	exitBadUsage    = 2
+)
+
+const (
+	errMissingInPath = "Error: path to css root module must be specified\n"
+	errMissingOutDir = "Error: path to css output dir must be specified\n"
+)
+
 func printErrorAndExit(error string) {
 	log.Printf(error)
 	flag.Usage()
-	os.Exit(1)
+	os.Exit(exitBadUsage)
 }
 
 func main() {
 	inPath := flag.String("in-path", "", "path to css root module (required)")
 	outDir := flag.String("out-dir", "", "path to css output dir (required)")
+	target := flag.String("target", "",
+		"comma separated list of engines to build for, e.g. "+
+			"chrome93,firefox92,safari14,edge93 (default: "+
+			"chrome67,firefox67,safari11.1,edge80)")
 	flag.Parse()
 	log.SetFlags(0)
 
 	if *inPath == "" {
-		printErrorAndExit("Error: path to css root module must be specified\n")
+		printErrorAndExit(errMissingInPath)
 	}
 
 	if *outDir == "" {
-		printErrorAndExit("Error: path to css out dir must be specified\n")
+		printErrorAndExit(errMissingOutDir)
 	}
 
+	if info, err := os.Stat(*inPath); err != nil || info.IsDir() {
+		printErrorAndExit(fmt.Sprintf("Error: css root module %s must be "+
+			"an existing file\n", *inPath))
+	}
+
+	if err := esbuildutils.PrepareOutputDir(*outDir); err != nil {
+		printErrorAndExit(fmt.Sprintf("Error: %s\n", err.Error()))
+	}
+
+	engines, err := esbuildutils.ParseEngines(*target)
+	if err != nil {
+		printErrorAndExit(fmt.Sprintf("Error: %s\n", err.Error()))
+	}
 
 	result := api.Build(api.BuildOptions{
 		MinifyWhitespace: true,
-		MinifySyntax: true,
+		MinifySyntax:     true,
 		EntryPoints: []string{
 			*inPath,
 		},
 		Loader: map[string]api.Loader{
 			".woff": api.LoaderDataURL,
-			".gif": api.LoaderDataURL,
+			".gif":  api.LoaderDataURL,
 		},
-		Bundle: true,
+		Bundle:           true,
 		PreserveSymlinks: true,
-		Outdir: *outDir,
-		Write: true,
-		LogLevel: api.LogLevelInfo,
-		Engines: []api.Engine{
-			{api.EngineChrome, "67"},
-			{api.EngineFirefox, "67"},
-			{api.EngineSafari, "11.1"},
-			{api.EngineEdge, "80"},
-		},
+		Outdir:           *outDir,
+		Write:            true,
+		LogLevel:         api.LogLevelSilent,
+		Engines:          engines,
 	})
 
+	esbuildutils.PrintMessages(os.Stderr, result, api.ColorIfTerminal)
 	if len(result.Errors) > 0 {
-		os.Exit(1)
+		os.Exit(exitBuildFailed)
 	}
 }
@@ -0,0 +1,102 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runMainEnv makes the test binary run main instead of the tests, so that
+// the tests can run minify_css as a process of its own.
+const runMainEnv = "MINIFY_CSS_TEST_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMinifyCSS runs minify_css with args in dir and returns its stderr and
+// exit code.
+func runMinifyCSS(t *testing.T, dir string, args ...string) (string, int) {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(exe, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), runMainEnv+"=1")
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatal(err)
+	}
+	return stderr.String(), cmd.ProcessState.ExitCode()
+}
+
+// newTestTree writes files, keyed by slash separated paths, to a temporary
+// dir and returns the dir.
+func newTestTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMissingOutDir(t *testing.T) {
+	dir := newTestTree(t, map[string]string{"app.css": "a { color: red }"})
+
+	stderr, code := runMinifyCSS(t, dir, "-in-path", "app.css")
+
+	if code != exitBadUsage || !strings.HasPrefix(stderr, errMissingOutDir) {
+		t.Errorf("expected exit code %d and %q, got %d:\n%s", exitBadUsage,
+			errMissingOutDir, code, stderr)
+	}
+}
+
+func TestBuildErrorPrinted(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"app.css": `@import "./missing.css";`,
+	})
+
+	stderr, code := runMinifyCSS(t, dir, "-in-path", "app.css",
+		"-out-dir", "out")
+
+	if code != exitBuildFailed {
+		t.Errorf("expected exit code %d, got %d", exitBuildFailed, code)
+	}
+	for _, text := range []string{
+		`[ERROR] Could not resolve "./missing.css"`,
+		"app.css:1:8:",
+	} {
+		if strings.Count(stderr, text) != 1 {
+			t.Errorf("expected %q once on stderr:\n%s", text, stderr)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+
+	"github.com/couchbase/ns_server/deps/gocode/jsbuild"
+)
+
+// configBuild is one of the builds described by the configs in -config-dir.
+type configBuild struct {
+	path string
+	cfg  jsbuild.Options
+}
+
+// listConfigDir returns the paths of the build configs in dir, sorted.
+func listConfigDir(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot list configs in %s: %s",
+			dir, err.Error())
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no build configs (*.json) found in %s", dir)
+	}
+	return paths, nil
+}
+
+// runBuilds runs builds on at most jobs workers and reports every result as
+// it completes. It returns the number of builds that failed and the number
+// of successful builds that exceeded their size budget.
+func runBuilds(builds []configBuild, jobs int) (failed, overBudget int) {
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+
+	queue := make(chan configBuild)
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer exitOnPanic()
+			for b := range queue {
+				result, err := jsbuild.Run(b.cfg)
+
+				mu.Lock()
+				printBuildMessages(b.cfg, result)
+				if b.cfg.DryRun {
+					printOutputFiles(result)
+				}
+				if err != nil {
+					failed++
+					log.Printf("%s: %s\n", b.path, err.Error())
+				} else if len(result.Errors) > 0 {
+					failed++
+					log.Printf("%s: build failed with %d error(s)\n",
+						b.path, len(result.Errors))
+				} else if result.OverBudget {
+					overBudget++
+					log.Printf("%s: build exceeds the size budget\n", b.path)
+				} else {
+					log.Printf("%s: build succeeded\n", b.path)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, b := range builds {
+		queue <- b
+	}
+	close(queue)
+	wg.Wait()
+
+	return failed, overBudget
+}
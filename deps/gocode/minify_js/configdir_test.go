@@ -0,0 +1,96 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// configFile returns a build config of the test tree that builds entry
+// into outDir, relative to the dir minify_js runs in.
+func configFile(entry, outDir string) string {
+	return fmt.Sprintf(`{
+		"inDir": ".",
+		"outDir": %q,
+		"importmapPath": "ui/importmap.json",
+		"entryPoints": [%q]
+	}`, outDir, entry)
+}
+
+func TestConfigDir(t *testing.T) {
+	files := map[string]string{
+		"ui/app/main.js":     `console.info("main");`,
+		"ui/app/login.js":    `console.info("login");`,
+		"ui/app/broken.js":   `let x = ;`,
+		"ok/main.json":       configFile("ui/app/main.js", "out-main"),
+		"ok/login.json":      configFile("ui/app/login.js", "out-login"),
+		"failed/main.json":   configFile("ui/app/main.js", "out-main"),
+		"failed/login.json":  configFile("ui/app/login.js", "out-login"),
+		"failed/broken.json": configFile("ui/app/broken.js", "out-broken"),
+	}
+	dir := newTestTree(t, files)
+
+	for _, test := range []struct {
+		configDir string
+		code      int
+		summary   string
+	}{
+		{"ok", 0, ""},
+		{"failed", exitBuildFailed, "1 of 3 builds failed"},
+	} {
+		run := runMinifyJS(t, dir, "", "-config-dir", test.configDir)
+
+		if run.code != test.code {
+			t.Errorf("%s: expected exit code %d, got %d:\n%s",
+				test.configDir, test.code, run.code, run.stderr)
+		}
+		for name := range files {
+			if !strings.HasPrefix(name, test.configDir+"/") {
+				continue
+			}
+			status := "build succeeded"
+			if strings.HasSuffix(name, "broken.json") {
+				status = "build failed with 1 error(s)"
+			}
+			if !strings.Contains(run.stderr, name+": "+status) {
+				t.Errorf("%s: %q is not reported:\n%s", test.configDir,
+					name+": "+status, run.stderr)
+			}
+		}
+		if !strings.Contains(run.stderr, test.summary) {
+			t.Errorf("%s: expected %q:\n%s", test.configDir, test.summary,
+				run.stderr)
+		}
+	}
+}
+
+func TestConfigDirTransformToStdout(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("main");`,
+		"configs/main.json": `{
+			"inDir": ".",
+			"transform": true,
+			"entryPoints": ["ui/app/main.js"]
+		}`,
+	})
+
+	run := runMinifyJS(t, dir, "", "-config-dir", "configs")
+
+	expected := "Error: -transform needs -out-dir with -config-dir " +
+		"(missing in configs/main.json)"
+	if run.code != exitBadUsage || !strings.HasPrefix(run.stderr, expected) {
+		t.Errorf("expected exit code %d and %q, got %d:\n%s", exitBadUsage,
+			expected, run.code, run.stderr)
+	}
+	if run.stdout != "" {
+		t.Errorf("unexpected output on stdout:\n%s", run.stdout)
+	}
+}
@@ -9,102 +9,803 @@
 package main
 
 import (
+	"crypto/sha256"
+	"errors"
 	"flag"
-	"os"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/couchbase/ns_server/deps/gocode/esbuildutils"
+	"github.com/couchbase/ns_server/deps/gocode/jsbuild"
 	"github.com/evanw/esbuild/pkg/api"
-	"io/ioutil"
-	"encoding/json"
 )
 
-type ImportMap struct {
-	Imports map[string]string
+// version is set at link time with -ldflags "-X main.version=..."
+var version = "dev"
+
+const (
+	exitBuildFailed = 1
+	exitBadUsage    = 2
+	exitOverBudget  = 3
+)
+
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return fmt.Sprintf("%v", *s)
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+type mapFlag map[string]string
+
+func (m *mapFlag) String() string {
+	return fmt.Sprintf("%v", *m)
+}
+
+func (m *mapFlag) Set(v string) error {
+	key, value, found := strings.Cut(v, "=")
+	if !found || key == "" {
+		return fmt.Errorf("'%s' is not in key=value form", v)
+	}
+	if *m == nil {
+		*m = make(mapFlag)
+	}
+	(*m)[key] = value
+	return nil
+}
+
+// optionalBoolFlag is a boolean flag that stays nil unless it is given
+// explicitly.
+type optionalBoolFlag struct {
+	value **bool
+}
+
+func (f optionalBoolFlag) String() string {
+	if f.value == nil || *f.value == nil {
+		return ""
+	}
+	return strconv.FormatBool(**f.value)
+}
+
+func (f optionalBoolFlag) Set(v string) error {
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return err
+	}
+	*f.value = &b
+	return nil
+}
+
+func (f optionalBoolFlag) IsBoolFlag() bool {
+	return true
+}
+
+// keepNamesFlag is a boolean flag that also accepts "all" and "none".
+// esbuild keeps either all function and class names or none of them, it
+// can't keep only those of functions, say.
+type keepNamesFlag bool
+
+func (f *keepNamesFlag) String() string {
+	if *f {
+		return "all"
+	}
+	return "none"
 }
 
-func getImportMapPlugin(importmapPath string, inDir string) (api.Plugin) {
-	plan, _ := ioutil.ReadFile(importmapPath)
-	var importmap ImportMap
-	if err := json.Unmarshal(plan, &importmap); err != nil {
-		panic(err)
+func (f *keepNamesFlag) Set(v string) error {
+	switch v {
+	case "all":
+		*f = true
+	case "none":
+		*f = false
+	default:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("expected all or none, esbuild can't " +
+				"keep only some of the names")
+		}
+		*f = keepNamesFlag(b)
 	}
+	return nil
+}
+
+func (f *keepNamesFlag) IsBoolFlag() bool {
+	return true
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage of %s:\n", os.Args[0])
+	flag.PrintDefaults()
+	fmt.Fprintf(out, "\nBy default bare imports are resolved through the "+
+		"import map given by -importmap-path\n(-resolve-mode=%s). "+
+		"With -resolve-mode=%s they are looked up in the\ndirectories "+
+		"given by -node-path instead. Both can be combined: the import map "+
+		"is\nthen tried first, and the imports missing from it are looked "+
+		"up in the -node-path\ndirs (with -resolve-mode=%s or "+
+		"-strict-importmap=false).\n",
+		jsbuild.ResolveModeImportMap, jsbuild.ResolveModeNodePaths,
+		jsbuild.ResolveModeNodePaths)
+	fmt.Fprintf(out, "\nOptions are taken from the built-in defaults, "+
+		"then from the -config file (or\neach file in -config-dir), then "+
+		"from the command line, each overriding the\nprevious one. "+
+		"Repeatable flags given on the command line replace the\n"+
+		"corresponding list from the config file, while key=value flags "+
+		"only override\nthe keys they name.\n")
+}
 
-	return api.Plugin{
-		Name: "ImportMap",
-		Setup: func (build api.PluginBuild) {
-			//we consider that this is bare specifier from importmap
-			//if es import string doesn't start with '/' or '.'
-			//or second symbol is not ':', so it covers the following
-			//cases '/' and './' and '../' and 'C:\\'
-			build.OnResolve(api.OnResolveOptions{Filter: `^[^\.\/][^:]`},
-				func (args api.OnResolveArgs) (api.OnResolveResult, error) {
-					return api.OnResolveResult{
-						Path: filepath.Join(
-							inDir, "ui",
-							importmap.Imports[args.Path]),
-					}, nil
-				})
-		},
+// printVersion prints the version of the tool, of the esbuild it was built
+// with and of the Go runtime.
+func printVersion() {
+	esbuildVersion := "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/evanw/esbuild" {
+				esbuildVersion = dep.Version
+			}
+		}
 	}
+	fmt.Printf("minify_js %s\nesbuild %s\n%s\n",
+		version, esbuildVersion, runtime.Version())
 }
 
 func printErrorAndExit(error string) {
 	log.Printf(error)
 	flag.Usage()
-	os.Exit(1)
+	os.Exit(exitBadUsage)
+}
+
+func registerFlags(cfg *jsbuild.Options) {
+	flag.StringVar(&cfg.InDir, "in-dir", cfg.InDir,
+		"path to js source dir (required)")
+	flag.StringVar(&cfg.OutDir, "out-dir", cfg.OutDir,
+		"path to js output dir (required)")
+	flag.StringVar(&cfg.ResolveMode, "resolve-mode", cfg.ResolveMode,
+		"how to resolve bare imports: "+jsbuild.ResolveModeImportMap+
+			" or "+jsbuild.ResolveModeNodePaths)
+	flag.Var((*stringsFlag)(&cfg.ImportMapPaths), "importmap-path",
+		"path to importmap.json (required in "+
+			jsbuild.ResolveModeImportMap+" resolve mode, optional in "+
+			jsbuild.ResolveModeNodePaths+" mode), or "+
+			jsbuild.ImportMapStdin+" to read it from stdin, or an http(s) "+
+			"URL to fetch it from; when repeated the maps are merged in "+
+			"order, later entries overriding earlier ones")
+	flag.BoolVar(&cfg.StrictImportMap, "strict-importmap",
+		cfg.StrictImportMap, "fail the build on bare imports missing from "+
+			"the import map; set to false to let esbuild resolve them, "+
+			"e.g. from node_modules")
+	flag.BoolVar(&cfg.VerifyImportMap, "verify-importmap",
+		cfg.VerifyImportMap, "check that every file the import map "+
+			"points at exists before building, and list those that don't")
+	flag.Var((*stringsFlag)(&cfg.NodePaths), "node-path",
+		"dir to look up bare imports in when in "+
+			jsbuild.ResolveModeNodePaths+" resolve mode, or with "+
+			"-strict-importmap=false those missing from the import map; "+
+			"the dirs listed in $NODE_PATH are looked up after these "+
+			"(can be repeated)")
+	flag.Var((*stringsFlag)(&cfg.EntryPoints), "entry",
+		"entry point, relative to -in-dir unless absolute, or a glob "+
+			"pattern like ui/widgets/*/index.js; given as name=path, e.g. "+
+			"app=ui/app/main.js, the output is named name.js instead of "+
+			"after the file; .css entry points are bundled into a "+
+			"stylesheet of their own (can be repeated, default: "+
+			"-entry-relative)")
+	flag.StringVar(&cfg.EntryRelative, "entry-relative", cfg.EntryRelative,
+		"entry point, relative to -in-dir, to build when no -entry is "+
+			"given")
+	flag.StringVar(&cfg.Target, "target", cfg.Target,
+		"comma separated list of engines to build for, e.g. "+
+			"chrome93,firefox92,safari14,edge93 (default: "+
+			"chrome67,firefox67,safari11.1,edge80)")
+	flag.StringVar(&cfg.Format, "format", cfg.Format,
+		"output format: esm, cjs or iife (code splitting is only "+
+			"available with esm)")
+	flag.StringVar(&cfg.Platform, "platform", cfg.Platform,
+		"platform to build for: browser, node (built-in modules are "+
+			"left unbundled) or neutral (no platform specific package "+
+			"fields or conditions)")
+	flag.StringVar(&cfg.GlobalName, "global-name", cfg.GlobalName,
+		"global variable, or dotted path like couchbase.ui, to assign "+
+			"the exports of the entry point to in iife format (required "+
+			"if it has any)")
+	flag.BoolVar(&cfg.TransformOnly, "transform", cfg.TransformOnly,
+		"only transform the one -entry, or stdin, with the same target "+
+			"and minification, leaving its imports as they are; the "+
+			"result is written to stdout without -out-dir")
+	flag.BoolVar(&cfg.DualFormat, "dual-format", cfg.DualFormat,
+		"build twice instead of in -format, as esm into <out-dir>/esm "+
+			"for modern browsers and as iife into <out-dir>/legacy for "+
+			"older ones (-global-name only applies to the latter)")
+	flag.StringVar(&cfg.Sourcemap, "sourcemap", cfg.Sourcemap,
+		"source map mode: linked (.map file plus sourceMappingURL "+
+			"comment), inline (map embedded in the output as a data "+
+			"URI, no .map file), external (.map file without the "+
+			"comment), both (inline and .map file) or none")
+	flag.BoolVar(&cfg.NoSourcemapComment, "no-sourcemap-comment",
+		cfg.NoSourcemapComment, "write .map files without referencing "+
+			"them from the outputs, same as -sourcemap=external")
+	flag.StringVar(&cfg.SourcesRoot, "sources-root", cfg.SourcesRoot,
+		"sourceRoot of the source maps, the URL their sources are "+
+			"resolved against instead of the map's own, e.g. to link "+
+			"them to the repository")
+	flag.StringVar(&cfg.MapDir, "write-map-dir", cfg.MapDir,
+		"dir to move the .map files to, keeping their paths relative to "+
+			"-out-dir; the outputs link them there if it is inside "+
+			"-out-dir, otherwise the links are removed")
+	flag.Var((*mapFlag)(&cfg.Defines), "define",
+		"replace a global identifier with a constant expression, in "+
+			"key=value form, e.g. -define VERSION='\"7.6.0\"' "+
+			"(can be repeated)")
+	flag.StringVar(&cfg.DefineEnv, "define-env", cfg.DefineEnv,
+		"comma separated list of environment variables NAME to replace "+
+			"process.env.NAME with, as a string, or with undefined if "+
+			"the variable is not set")
+	flag.BoolVar(&cfg.DefineEnvStrict, "define-env-strict",
+		cfg.DefineEnvStrict, "fail if a -define-env variable is not set")
+	flag.Var((*stringsFlag)(&cfg.FeaturesOff), "feature-off",
+		"feature to build without: the modules starting with a "+
+			"\"// @feature name\" comment for it are replaced with empty "+
+			"ones, leaving out everything only they import (can be "+
+			"repeated)")
+	flag.Var((*mapFlag)(&cfg.Loaders), "loader",
+		"loader for a file extension in .ext=loader form, e.g. "+
+			".svg=text, or .png=dataurl to inline small images as data "+
+			"URLs; applies to import mapped modules as well; merged with "+
+			"the default .html, .jsx, .ts and .tsx loaders (can be "+
+			"repeated)")
+	flag.StringVar(&cfg.DefaultLoader, "loader-default", cfg.DefaultLoader,
+		"loader for files with an extension that has no loader, e.g. "+
+			"text, file or copy (default: such imports fail)")
+	flag.Var((*mapFlag)(&cfg.FileLoaders), "file-loader",
+		"loader for the files matching a pattern relative to -in-dir, "+
+			"in pattern=loader form, e.g. ui/app/icons/*.svg=text; "+
+			"overrides -loader, the longest matching pattern wins (can "+
+			"be repeated)")
+	flag.StringVar(&cfg.ResolveExtensions, "resolve-extensions",
+		cfg.ResolveExtensions, "comma separated list of extensions to "+
+			"try, in order, for imports without one, e.g. .js,.mjs,.json "+
+			"(default: .tsx,.ts,.jsx,.js,.css,.json)")
+	flag.StringVar(&cfg.Conditions, "conditions", cfg.Conditions,
+		"comma separated list of custom conditions to match in the "+
+			"exports field of package.json, e.g. development, on top of "+
+			"the -platform one, import or require and default which "+
+			"always apply (default: module)")
+	flag.StringVar(&cfg.MainFields, "main-fields", cfg.MainFields,
+		"comma separated list of the package.json fields to take the "+
+			"entry point of a package from, in order, e.g. module,main "+
+			"(default: browser,module,main; main,module with "+
+			"-platform=node; none with -platform=neutral)")
+	flag.StringVar(&cfg.Tsconfig, "tsconfig", cfg.Tsconfig,
+		"tsconfig.json, relative to -in-dir unless absolute, to take "+
+			"the paths aliases and the JSX and other compiler options "+
+			"from; imports of its aliases are allowed with "+
+			"-strict-importmap")
+	flag.Var((*mapFlag)(&cfg.Aliases), "alias",
+		"replace imports of a package with another one or a file, in "+
+			"from=to form, e.g. lib=./vendor/lib-fork.js with the file "+
+			"relative to -in-dir; applied before the import map and "+
+			"-node-path (can be repeated)")
+	flag.Var((*stringsFlag)(&cfg.Externals), "external",
+		"module to leave unbundled, it may contain a single '*' "+
+			"wildcard (can be repeated)")
+	flag.Var((*stringsFlag)(&cfg.Injects), "inject",
+		"file, relative to -in-dir unless absolute, whose exports "+
+			"replace the globals of the same name in every module "+
+			"(can be repeated)")
+	flag.Var((*keepNamesFlag)(&cfg.KeepNames), "keep-names",
+		"which function and class names to preserve: all or none "+
+			"(also true or false); identifiers are only minified with "+
+			"none")
+	flag.BoolVar(&cfg.Minify, "minify", cfg.Minify,
+		"minify whitespace, syntax and identifiers (identifiers are "+
+			"left alone while -keep-names is on)")
+	flag.Var(optionalBoolFlag{&cfg.MinifyWhitespace}, "minify-whitespace",
+		"minify whitespace (default: same as -minify)")
+	flag.Var(optionalBoolFlag{&cfg.MinifySyntax}, "minify-syntax",
+		"minify syntax (default: same as -minify)")
+	flag.Var(optionalBoolFlag{&cfg.MinifyIdentifiers}, "minify-identifiers",
+		"minify identifiers (default: -minify unless -keep-names)")
+	flag.Var((*stringsFlag)(&cfg.NoMinifyGlobs), "no-minify-glob",
+		"glob pattern like ui/legacy/*.js, relative to -in-dir, of "+
+			"modules to keep out of the bundle and copy to -out-dir as "+
+			"they are, e.g. if they build code from their own source; "+
+			"they are imported from under -public-path at runtime, which "+
+			"needs esm format, so they have to be plain JavaScript and "+
+			"their imports are not bundled (can be repeated)")
+	flag.StringVar(&cfg.LegalComments, "legal-comments", cfg.LegalComments,
+		"where to put legal comments: none, inline, eof, linked "+
+			"(.LEGAL.txt file plus a link comment) or external "+
+			"(.LEGAL.txt file only) (default: eof)")
+	flag.Var((*stringsFlag)(&cfg.PureFunctions), "pure",
+		"function whose calls have no side effects and can be removed "+
+			"when unused, added to the default console.log; pass "+
+			"-pure="+jsbuild.PureNone+" to drop the default (can be "+
+			"repeated)")
+	flag.StringVar(&cfg.MangleProps, "mangle-props", cfg.MangleProps,
+		"regexp of the property names to mangle, e.g. _$ for the ones "+
+			"ending in an underscore; unsafe unless every access to them "+
+			"is part of the bundle")
+	flag.StringVar(&cfg.ReserveProps, "reserve-props", cfg.ReserveProps,
+		"regexp of the property names matching -mangle-props to leave "+
+			"alone")
+	flag.BoolVar(&cfg.PreserveSymlinks, "preserve-symlinks",
+		cfg.PreserveSymlinks, "resolve symlinked modules at the link "+
+			"rather than at their target; set to false to follow links, "+
+			"e.g. into a pnpm store")
+	flag.BoolVar(&cfg.TreeShaking, "tree-shaking", cfg.TreeShaking,
+		"remove unused code from the output")
+	flag.BoolVar(&cfg.IgnoreAnnotations, "ignore-annotations",
+		cfg.IgnoreAnnotations, "ignore /* @__PURE__ */ comments and the "+
+			"sideEffects field of package.json, for dependencies whose "+
+			"annotations are wrong")
+	flag.BoolVar(&cfg.ReportUnused, "report-unused", cfg.ReportUnused,
+		"list the modules that end up with no code in the output on "+
+			"stderr")
+	flag.BoolVar(&cfg.DetectCycles, "detect-cycles", cfg.DetectCycles,
+		"list the chains of modules importing each other, which make "+
+			"their initialization order depend on what imports them "+
+			"first, on stderr (dynamic imports are left out)")
+	flag.BoolVar(&cfg.CyclesFatal, "cycles-fatal", cfg.CyclesFatal,
+		"with -detect-cycles, fail the build if there are any")
+	flag.BoolVar(&cfg.DetectDuplicates, "detect-duplicates",
+		cfg.DetectDuplicates, "list the modules bundled more than once "+
+			"on stderr: packages bundled from several dirs, e.g. once "+
+			"through the import map and once from a -node-path, and "+
+			"files with the same contents under different paths")
+	flag.BoolVar(&cfg.DuplicatesFatal, "duplicates-fatal",
+		cfg.DuplicatesFatal, "with -detect-duplicates, fail the build "+
+			"if there are any")
+	flag.BoolVar(&cfg.Summary, "summary", cfg.Summary,
+		"list the outputs of the build, with their sizes and whether "+
+			"they are entry points, shared chunks, source maps or "+
+			"assets, on stderr")
+	flag.BoolVar(&cfg.KeepConsole, "keep-console", cfg.KeepConsole,
+		"keep console.log calls even when their result is unused, by "+
+			"not treating console.log as pure")
+	flag.StringVar(&cfg.Drop, "drop", cfg.Drop,
+		"comma separated list of statements to remove from the output: "+
+			"console (all console.* calls) and/or debugger")
+	flag.StringVar(&cfg.JSX, "jsx", cfg.JSX,
+		"how to compile JSX: transform (calls to -jsx-factory), "+
+			"preserve (left as is) or automatic (the react/jsx-runtime "+
+			"functions) (default: transform)")
+	flag.StringVar(&cfg.JSXFactory, "jsx-factory", cfg.JSXFactory,
+		"function JSX elements are compiled to (default: "+
+			"React.createElement)")
+	flag.StringVar(&cfg.JSXFragment, "jsx-fragment", cfg.JSXFragment,
+		"value JSX fragments are compiled to (default: React.Fragment)")
+	flag.StringVar(&cfg.Charset, "charset", cfg.Charset,
+		"output charset: ascii (non-ASCII characters are escaped) or "+
+			"utf8 (they are written as is)")
+	flag.StringVar(&cfg.Banner, "banner", cfg.Banner,
+		"text to prepend to the js output, or @path to read it from a file")
+	flag.StringVar(&cfg.Footer, "footer", cfg.Footer,
+		"text to append to the js output, or @path to read it from a file")
+	flag.StringVar(&cfg.EntryNames, "entry-names", cfg.EntryNames,
+		"template for entry point output paths, e.g. [dir]/[name]-[hash]; "+
+			"when set, "+jsbuild.ManifestFileName+" mapping entry points to "+
+			"their outputs is written to -out-dir (default: [dir]/[name])")
+	flag.StringVar(&cfg.ManifestPath, "write-manifest", cfg.ManifestPath,
+		"path to write the manifest mapping entry points, or the names "+
+			"of those given as name=path, to their outputs to, instead "+
+			"of "+jsbuild.ManifestFileName+" in -out-dir with "+
+			"-entry-names")
+	flag.StringVar(&cfg.ManifestSchema, "manifest-schema",
+		cfg.ManifestSchema, "shape of the manifest: "+
+			jsbuild.ManifestSchemaFlat+" (entry point to output path) or "+
+			jsbuild.ManifestSchemaWebpack+" (entry point to {file,css,"+
+			"imports}, with the css bundle and the chunks it imports)")
+	flag.BoolVar(&cfg.Splitting, "splitting", cfg.Splitting,
+		"move code shared by entry points into chunks of their own; "+
+			"when disabled every entry point gets a self-contained "+
+			"bundle (only available with esm)")
+	flag.StringVar(&cfg.ChunkNames, "chunk-names", cfg.ChunkNames,
+		"template for shared chunk output paths (default: [name]-[hash])")
+	flag.StringVar(&cfg.ChunkDir, "chunk-dir", cfg.ChunkDir,
+		"subdir of -out-dir to put shared chunks in, e.g. chunks")
+	flag.StringVar(&cfg.AssetNames, "asset-names", cfg.AssetNames,
+		"template for asset output paths (default: [name]-[hash])")
+	flag.Var((*mapFlag)(&cfg.OutExtensions), "out-extension",
+		"extension to give the .js or .css outputs instead, in "+
+			".ext=.ext form, e.g. .js=.mjs; source maps, the manifest "+
+			"and the imports between chunks follow it (can be repeated)")
+	flag.StringVar(&cfg.PublicPath, "public-path", cfg.PublicPath,
+		"prefix for the URLs of assets emitted by the file loader")
+	flag.StringVar(&cfg.Metafile, "metafile", cfg.Metafile,
+		"path to write the build metafile (JSON) to")
+	flag.StringVar(&cfg.GraphOut, "graph-out", cfg.GraphOut,
+		"path to write the module graph to, as a Graphviz DOT file")
+	flag.BoolVar(&cfg.Integrity, "integrity", cfg.Integrity,
+		"write SHA-384 subresource integrity hashes of the emitted .js "+
+			"and .css files to "+jsbuild.IntegrityFileName+" in -out-dir")
+	flag.StringVar(&cfg.MaxSize, "max-size", cfg.MaxSize,
+		"fail with exit code 3 if the emitted .js files take more than "+
+			"this many bytes in total, or with -watch report every "+
+			"rebuild that does; k and m suffixes are accepted, e.g. 512k")
+	flag.StringVar(&cfg.Compress, "compress", cfg.Compress,
+		"comma separated list of gzip and/or brotli; writes .gz and .br "+
+			"variants of the .js and .css outputs where they are smaller")
+	flag.StringVar(&cfg.PostBuild, "post-build", cfg.PostBuild,
+		"shell command to run after every successful build within "+
+			"-max-size, including those restored from -cache-dir, once "+
+			"the -compress variants are written, with MINIFY_OUT_DIR "+
+			"set to -out-dir; the build fails if it does")
+	flag.StringVar(&cfg.CacheDir, "cache-dir", cfg.CacheDir,
+		"dir to cache builds in; when neither the config nor any input "+
+			"changed since the last build, its outputs are restored "+
+			"from there without running esbuild (esbuild's own cache "+
+			"doesn't outlive the process); not with -summary, -timing, "+
+			"-report-unused, -detect-cycles or -detect-duplicates")
+	flag.BoolVar(&cfg.Timing, "timing", cfg.Timing,
+		"print how long the build took on stderr, along with the "+
+			"largest inputs when -metafile is given")
+	flag.StringVar(&cfg.Color, "color", cfg.Color,
+		"whether to color the errors and warnings: auto (if stderr is a "+
+			"terminal), always or never")
+	flag.BoolVar(&cfg.WarningsAsErrors, "warnings-as-errors",
+		cfg.WarningsAsErrors, "fail the build if esbuild reports any "+
+			"warnings")
+	flag.BoolVar(&cfg.Quiet, "quiet", cfg.Quiet,
+		"only print errors, not warnings or the list of outputs")
+	flag.BoolVar(&cfg.Verbose, "verbose", cfg.Verbose,
+		"also print esbuild's debug messages, such as the warnings it "+
+			"hides by default")
+	flag.IntVar(&cfg.LogLimit, "log-limit", cfg.LogLimit,
+		"max number of errors and warnings to print, 0 for no limit")
+	flag.StringVar(&cfg.LogFormat, "log-format", cfg.LogFormat,
+		"how to report errors and warnings: "+jsbuild.LogFormatText+
+			" (formatted as esbuild does, on stderr) or "+
+			jsbuild.LogFormatJSON+" (a JSON array of {file,line,column,"+
+			"text,level} objects on stdout, esbuild output is silenced)")
+	flag.BoolVar(&cfg.DryRun, "dry-run", cfg.DryRun,
+		"build without writing anything, list the paths and sizes of "+
+			"the files that would be written on stdout instead")
+	flag.StringVar(&cfg.Archive, "archive", cfg.Archive,
+		"write the outputs, with their paths relative to -out-dir, to "+
+			"this .tar.gz, .tgz or .zip archive instead of to -out-dir")
+	flag.BoolVar(&cfg.Stdin, "stdin", cfg.Stdin,
+		"read the entry point source from stdin; without -out-dir the "+
+			"bundle is written to stdout (with no code splitting and "+
+			"only inline source maps)")
+	flag.StringVar(&cfg.StdinLoader, "stdin-loader", cfg.StdinLoader,
+		"loader for the source read from stdin: js, ts or jsx")
+	flag.StringVar(&cfg.StdinResolveDir, "stdin-resolve-dir",
+		cfg.StdinResolveDir, "dir to resolve relative imports of the "+
+			"source read from stdin against (default: -in-dir)")
+}
+
+// resetRepeatedFlag clears the values of a repeatable flag, so re-parsing
+// the command line replaces them instead of appending to them.
+func resetRepeatedFlag(f *flag.Flag) {
+	if values, ok := f.Value.(*stringsFlag); ok {
+		*values = nil
+	}
+}
+
+// printOutputFiles lists the paths and sizes of the files a build would
+// have written.
+func printOutputFiles(result jsbuild.Result) {
+	for _, file := range result.OutputFiles {
+		fmt.Printf("%s\t%d\n", file.Path, len(file.Contents))
+	}
+}
+
+// printFingerprint prints a SHA-256 hash of the outputs of a build, with
+// their paths relative to outDir, in path order. Builds of the same inputs
+// with the same options have the same fingerprint.
+func printFingerprint(result jsbuild.Result, outDir string) {
+	files := slices.Clone(result.OutputFiles)
+	for i := range files {
+		if rel, err := filepath.Rel(outDir, files[i].Path); err == nil {
+			files[i].Path = filepath.ToSlash(rel)
+		}
+	}
+	slices.SortFunc(files, func(a, b api.OutputFile) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	hash := sha256.New()
+	for _, file := range files {
+		hash.Write([]byte(file.Path))
+		hash.Write([]byte{0})
+		hash.Write(file.Contents)
+	}
+	fmt.Printf("%x\n", hash.Sum(nil))
+}
+
+// printOutputList lists the paths, relative to the current dir, and sizes
+// of the files a build wrote on stderr.
+func printOutputList(result jsbuild.Result) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	for _, file := range result.OutputFiles {
+		path := file.Path
+		if rel, err := filepath.Rel(wd, path); err == nil {
+			path = rel
+		}
+		fmt.Fprintf(os.Stderr, "  %s  %s\n", path,
+			jsbuild.FormatSize(len(file.Contents)))
+	}
+}
+
+func printBuildMessages(cfg jsbuild.Options, result jsbuild.Result) {
+	messages := api.BuildResult{
+		Errors:   result.Errors,
+		Warnings: result.Warnings,
+	}
+	if cfg.Quiet {
+		messages.Warnings = nil
+	}
+
+	if cfg.LogFormat == jsbuild.LogFormatJSON {
+		err := esbuildutils.PrintMessagesJSON(os.Stdout, messages)
+		if err != nil {
+			log.Printf("Error: cannot print messages: %s\n", err.Error())
+		}
+		return
+	}
+	// with -verbose esbuild logs the messages itself, and jsbuild those it
+	// adds after the build
+	if cfg.Verbose {
+		return
+	}
+
+	// errors go first, the same as in esbuild's own output
+	shown := messages
+	if limit := cfg.LogLimit; limit > 0 {
+		shown.Errors = shown.Errors[:min(limit, len(shown.Errors))]
+		limit -= len(shown.Errors)
+		shown.Warnings = shown.Warnings[:min(limit, len(shown.Warnings))]
+	}
+	color, _ := esbuildutils.ParseColor(cfg.Color)
+	esbuildutils.PrintMessages(os.Stderr, shown, color)
+
+	hidden := len(messages.Errors) + len(messages.Warnings) -
+		len(shown.Errors) - len(shown.Warnings)
+	if hidden > 0 {
+		log.Printf("%d more message(s) not shown, see -log-limit\n", hidden)
+	}
+}
+
+// exitOnError exits with a usage error if err is an *jsbuild.OptionsError,
+// and as a failed build otherwise.
+func exitOnError(err error) {
+	var optsErr *jsbuild.OptionsError
+	if errors.As(err, &optsErr) {
+		printErrorAndExit(fmt.Sprintf("Error: %s\n", err.Error()))
+	}
+	log.Printf("Error: %s\n", err.Error())
+	os.Exit(exitBuildFailed)
+}
+
+// addEnvNodePaths appends the dirs listed in $NODE_PATH, separated the way
+// the OS separates $PATH, to the node paths of *cfg, the same as node does.
+func addEnvNodePaths(cfg *jsbuild.Options) {
+	for _, dir := range filepath.SplitList(os.Getenv("NODE_PATH")) {
+		if dir != "" {
+			cfg.NodePaths = append(cfg.NodePaths, dir)
+		}
+	}
+}
+
+// readConfigWithFlags reads the config at path into *cfg, which the flags
+// are bound to, and applies the command line flags on top of it.
+func readConfigWithFlags(cfg *jsbuild.Options, path string) jsbuild.Options {
+	fileCfg, err := jsbuild.ReadOptions(path)
+	if err != nil {
+		printErrorAndExit(fmt.Sprintf("Error: %s\n", err.Error()))
+	}
+	*cfg = fileCfg
+
+	// command line flags take precedence over the config file
+	flag.Visit(resetRepeatedFlag)
+	flag.CommandLine.Parse(os.Args[1:])
+	return *cfg
+}
+
+// buildConfigDir builds every config in dir, with the command line flags
+// applied to each of them, and exits non-zero if any of the builds fails.
+func buildConfigDir(cfg *jsbuild.Options, dir string, jobs int) {
+	paths, err := listConfigDir(dir)
+	if err != nil {
+		printErrorAndExit(fmt.Sprintf("Error: %s\n", err.Error()))
+	}
+
+	builds := make([]configBuild, 0, len(paths))
+	for _, path := range paths {
+		pathCfg := readConfigWithFlags(cfg, path)
+		if pathCfg.Stdin {
+			printErrorAndExit(fmt.Sprintf("Error: -stdin can't be used "+
+				"with -config-dir (set in %s)\n", path))
+		}
+		// the outputs of concurrent builds can't share stdout
+		if pathCfg.TransformOnly && pathCfg.OutDir == "" {
+			printErrorAndExit(fmt.Sprintf("Error: -transform needs "+
+				"-out-dir with -config-dir (missing in %s)\n", path))
+		}
+		addEnvNodePaths(&pathCfg)
+		builds = append(builds, configBuild{path: path, cfg: pathCfg})
+	}
+
+	failed, overBudget := runBuilds(builds, jobs)
+	if failed > 0 {
+		log.Printf("%d of %d builds failed\n", failed, len(builds))
+		os.Exit(exitBuildFailed)
+	}
+	if overBudget > 0 {
+		log.Printf("%d of %d builds exceed their size budget\n",
+			overBudget, len(builds))
+		os.Exit(exitOverBudget)
+	}
 }
 
 func main() {
-	inDir := flag.String("in-dir", "", "path to js source dir (required)")
-	outDir := flag.String("out-dir", "", "path to js output dir (required)")
-	importmapPath := flag.String("importmap-path", "", "path to importmap.json (required)")
+	defer exitOnPanic()
+
+	cfg := jsbuild.DefaultOptions()
+
+	configPath := flag.String("config", "",
+		"path to a JSON build config (see below)")
+	registerFlags(&cfg)
+	watchMode := flag.Bool("watch", false,
+		"keep running and rebuild whenever sources change")
+	serve := flag.Bool("serve", false,
+		"like -watch, but also serve -out-dir over HTTP")
+	configDir := flag.String("config-dir", "",
+		"build every *.json config in this dir, running builds "+
+			"concurrently")
+	jobs := flag.Int("jobs", 0,
+		"max number of CPUs esbuild may use at once, e.g. to leave "+
+			"some to other jobs on a shared machine, and of concurrent "+
+			"builds with -config-dir (default: all)")
+	watchDebounce := flag.Duration("watch-debounce", 100*time.Millisecond,
+		"with -watch or -serve, how long to wait after a change before "+
+			"rebuilding, so that changes made within it trigger a "+
+			"single rebuild")
+	watchGranular := flag.Bool("watch-granular", false,
+		"with -watch or -serve, give every entry point a build of its "+
+			"own, so that a change only rebuilds the entry points that "+
+			"depend on it (there are no shared chunks then)")
+	var watchIgnores stringsFlag
+	flag.Var(&watchIgnores, "watch-ignore",
+		"with -watch or -serve, glob pattern like **/node_modules/**, "+
+			"relative to -in-dir, of files not to watch, so that "+
+			"changes to them don't trigger rebuilds (can be repeated)")
+	servePort := flag.Int("serve-port", 0,
+		"port to serve on with -serve (default: first free port "+
+			"from 8000)")
+	fingerprint := flag.Bool("fingerprint", false,
+		"build without writing anything and print a SHA-256 hash of "+
+			"the outputs on stdout instead, to check that builds are "+
+			"reproducible")
+	showVersion := flag.Bool("version", false,
+		"print the versions of minify_js, esbuild and Go and exit")
+	flag.Usage = usage
 	flag.Parse()
 	log.SetFlags(0)
+	jsbuild.Version = version
 
-	if *inDir == "" {
-		printErrorAndExit("Error: path to js source dir must be specified\n")
-	}
-
-	if *outDir == "" {
-		printErrorAndExit("Error: path to js source dir must be specified\n")
-	}
-
-	if *importmapPath == "" {
-		printErrorAndExit("Error: path to importmap.json must be specified\n")
-	}
-
-	result := api.Build(api.BuildOptions{
-		MinifyWhitespace: true,
-		// TODO: figure out why does't work
-		// MinifyIdentifiers: true,
-		MinifySyntax: true,
-		EntryPoints: []string{
-			*inDir + "/ui/app/main.js",
-		},
-		Pure: []string{"console.log"},
-		Plugins: []api.Plugin{getImportMapPlugin(*importmapPath, *inDir)},
-		Sourcemap: api.SourceMapLinked,
-		KeepNames: true,
-		Bundle: true,
-		PreserveSymlinks: true,
-		Splitting: true,
-		Write: true,
-		Format: api.FormatESModule,
-		// LogLevel: api.LogLevelWarning,
-		LogLevel: api.LogLevelInfo,
-		Outdir: *outDir,
-		Loader: map[string]api.Loader{
-			".html": api.LoaderText,
-		},
-		Engines: []api.Engine{
-			{api.EngineChrome, "67"},
-			{api.EngineFirefox, "67"},
-			{api.EngineSafari, "11.1"},
-			{api.EngineEdge, "80"},
-		},
-	})
+	if *showVersion {
+		printVersion()
+		return
+	}
+
+	if *jobs < 0 {
+		printErrorAndExit("Error: -jobs can't be negative\n")
+	}
+	if *jobs > 0 {
+		// esbuild runs its work on goroutines, which this caps
+		runtime.GOMAXPROCS(*jobs)
+	}
+
+	if *configPath != "" && *configDir != "" {
+		printErrorAndExit("Error: -config and -config-dir can't be used " +
+			"together\n")
+	}
+
+	if *configPath != "" {
+		cfg = readConfigWithFlags(&cfg, *configPath)
+	}
+	addEnvNodePaths(&cfg)
+
+	if *fingerprint {
+		if *watchMode || *serve || *configDir != "" {
+			printErrorAndExit("Error: -fingerprint can't be used with " +
+				"-watch, -serve or -config-dir\n")
+		}
+		cfg.DryRun = true
+	}
+
+	if *configDir != "" {
+		if *watchMode || *serve {
+			printErrorAndExit("Error: -watch and -serve can't be used " +
+				"with -config-dir\n")
+		}
+		// one build per CPU it may use
+		buildConfigDir(&cfg, *configDir, runtime.GOMAXPROCS(0))
+		return
+	}
+
+	if *watchMode || *serve {
+		stop := make(chan struct{})
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		go func() {
+			log.Printf("Received %v, stopping\n", <-signals)
+			close(stop)
+		}()
+
+		watchOpts := jsbuild.WatchOptions{
+			Serve:     *serve,
+			ServePort: *servePort,
+			Debounce:  *watchDebounce,
+			Granular:  *watchGranular,
+			Ignore:    watchIgnores,
+			Stop:      stop,
+		}
+		err := jsbuild.Watch(cfg, watchOpts, func(result jsbuild.Result) {
+			printBuildMessages(cfg, result)
+		})
+		if err != nil {
+			exitOnError(err)
+		}
+		return
+	}
+
+	result, err := jsbuild.Run(cfg)
+	if err != nil {
+		exitOnError(err)
+	}
+	printBuildMessages(cfg, result)
+	// as esbuild does, which only logs on its own with -verbose
+	if len(result.Errors) == 0 && cfg.LogFormat == jsbuild.LogFormatText &&
+		cfg.OutDir != "" && !cfg.DryRun && !cfg.Quiet && !cfg.Verbose {
+		printOutputList(result)
+	}
 
 	if len(result.Errors) > 0 {
-		os.Exit(1)
+		os.Exit(exitBuildFailed)
+	}
+
+	if result.OverBudget {
+		os.Exit(exitOverBudget)
+	}
+
+	if *fingerprint {
+		outDir, err := filepath.Abs(cfg.OutDir)
+		if err != nil {
+			exitOnError(err)
+		}
+		printFingerprint(result, outDir)
+		return
+	}
+
+	if cfg.DryRun {
+		printOutputFiles(result)
+		return
+	}
+
+	// without -out-dir the stdin bundle, or the transformed file, is kept
+	// in memory
+	if (cfg.Stdin || cfg.TransformOnly) && cfg.OutDir == "" {
+		for _, file := range result.OutputFiles {
+			os.Stdout.Write(file.Contents)
+		}
 	}
 }
@@ -0,0 +1,815 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// runMainEnv makes the test binary run main instead of the tests, so that
+// the tests can run minify_js as a process of its own.
+const runMainEnv = "MINIFY_JS_TEST_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runResult is the outcome of running minify_js.
+type runResult struct {
+	stdout string
+	stderr string
+	code   int
+}
+
+// runMinifyJS runs minify_js with args in dir, with stdin as its input.
+func runMinifyJS(t *testing.T, dir, stdin string, args ...string) runResult {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runExecutable(t, exe, dir, stdin, args...)
+}
+
+// runExecutable runs exe, the test binary or a build of minify_js, like
+// runMinifyJS.
+func runExecutable(t *testing.T, exe, dir, stdin string,
+	args ...string) runResult {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(exe, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), runMainEnv+"=1")
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatal(err)
+	}
+	return runResult{
+		stdout: stdout.String(),
+		stderr: stderr.String(),
+		code:   cmd.ProcessState.ExitCode(),
+	}
+}
+
+// newTestTree writes files, keyed by slash separated paths, to a temporary
+// dir laid out like the ns_server UI tree, with the entry point at
+// ui/app/main.js and the import map at ui/importmap.json, which is empty
+// unless given in files. It returns the dir.
+func newTestTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	all := map[string]string{"ui/importmap.json": `{"imports": {}}`}
+	for name, contents := range files {
+		all[name] = contents
+	}
+	for name, contents := range all {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// treeArgs are the arguments to build a test tree in the current dir into
+// its out dir.
+var treeArgs = []string{"-in-dir", ".", "-out-dir", "out",
+	"-importmap-path", "ui/importmap.json"}
+
+// buildArgs returns treeArgs followed by args.
+func buildArgs(args ...string) []string {
+	return append(append([]string{}, treeArgs...), args...)
+}
+
+// readTree returns the contents of the files under dir by their slash
+// separated paths relative to it.
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry,
+		err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestJobs(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": `import { shared } from "./shared.js";
+			console.info(shared, import("./lazy.js"));`,
+		"ui/app/shared.js": `export const shared = "shared";`,
+		"ui/app/lazy.js":   `export const lazy = "lazy";`,
+	})
+
+	trees := make([]map[string]string, 2)
+	for i, args := range [][]string{nil, {"-jobs=1"}} {
+		outDir := fmt.Sprintf("out%d", i)
+		args = append([]string{"-out-dir", outDir}, args...)
+		run := runMinifyJS(t, dir, "", buildArgs(args...)...)
+		if run.code != 0 {
+			t.Fatalf("minify_js %v exited with %d:\n%s",
+				args, run.code, run.stderr)
+		}
+		trees[i] = readTree(t, filepath.Join(dir, outDir))
+	}
+
+	if len(trees[0]) < 3 {
+		t.Errorf("expected main.js, a chunk and their maps, got %d files",
+			len(trees[0]))
+	}
+	for name, contents := range trees[0] {
+		if trees[1][name] != contents {
+			t.Errorf("%s differs with -jobs=1:\n%s", name, trees[1][name])
+		}
+	}
+	if len(trees[1]) != len(trees[0]) {
+		t.Errorf("-jobs=1 produces %d files, the default %d",
+			len(trees[1]), len(trees[0]))
+	}
+}
+
+func TestJobsNegative(t *testing.T) {
+	dir := newTestTree(t, nil)
+
+	run := runMinifyJS(t, dir, "", buildArgs("-jobs=-1")...)
+
+	if run.code != exitBadUsage ||
+		!strings.Contains(run.stderr, "-jobs can't be negative") {
+		t.Errorf("unexpected exit code %d:\n%s", run.code, run.stderr)
+	}
+}
+
+// buildKeepNames returns main.js of a tree with long names built with each
+// of the -keep-names values.
+func buildKeepNames(t *testing.T, values ...string) map[string]string {
+	t.Helper()
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": `function computeTheAnswer(seed) {
+				const aVeryLongLocalVariableName = seed * Math.random();
+				return aVeryLongLocalVariableName * aVeryLongLocalVariableName +
+					aVeryLongLocalVariableName;
+			}
+			console.info(computeTheAnswer(42));`,
+	})
+
+	outputs := make(map[string]string)
+	for _, keepNames := range values {
+		outDir := "out-" + keepNames
+		run := runMinifyJS(t, dir, "", buildArgs("-out-dir", outDir,
+			"-keep-names="+keepNames)...)
+		if run.code != 0 {
+			t.Fatalf("-keep-names=%s exited with %d:\n%s",
+				keepNames, run.code, run.stderr)
+		}
+		outputs[keepNames] = readTree(t, filepath.Join(dir, outDir))["main.js"]
+	}
+	return outputs
+}
+
+func TestKeepNames(t *testing.T) {
+	outputs := buildKeepNames(t, "true", "false")
+
+	if !strings.Contains(outputs["true"], "aVeryLongLocalVariableName") {
+		t.Errorf("identifiers are minified with -keep-names:\n%s",
+			outputs["true"])
+	}
+	if strings.Contains(outputs["false"], "aVeryLongLocalVariableName") {
+		t.Errorf("identifiers are not minified with -keep-names=false:\n%s",
+			outputs["false"])
+	}
+	if len(outputs["false"]) >= len(outputs["true"]) {
+		t.Errorf("-keep-names=false doesn't shrink the output: %d bytes, "+
+			"%d with -keep-names", len(outputs["false"]),
+			len(outputs["true"]))
+	}
+}
+
+func TestKeepNamesModes(t *testing.T) {
+	outputs := buildKeepNames(t, "all", "none", "true", "false")
+
+	if outputs["all"] != outputs["true"] ||
+		outputs["none"] != outputs["false"] {
+		t.Errorf("all and none don't build like true and false:\n%v", outputs)
+	}
+	if len(outputs["none"]) >= len(outputs["all"]) {
+		t.Errorf("-keep-names=none builds %d bytes, not less than the %d "+
+			"of all", len(outputs["none"]), len(outputs["all"]))
+	}
+
+	var f keepNamesFlag
+	if err := f.Set("functions"); err == nil {
+		t.Error("keeping only the function names is accepted")
+	}
+}
+
+func TestMissingImportMap(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info(1);`,
+	})
+
+	run := runMinifyJS(t, dir, "", buildArgs("-importmap-path",
+		"ui/missing.json")...)
+
+	if run.code == 0 {
+		t.Fatal("the build succeeds without an import map")
+	}
+	if !strings.Contains(run.stderr, "cannot read import map at "+
+		"ui/missing.json: ") {
+		t.Errorf("the error doesn't name the import map:\n%s", run.stderr)
+	}
+	if strings.Contains(run.stderr, "goroutine 1 [") {
+		t.Errorf("minify_js crashes:\n%s", run.stderr)
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info(1);`,
+		"build.json": `{
+			"inDir": ".",
+			"outDir": "out",
+			"importmapPath": "ui/importmap.json",
+			"banner": "/* config banner */",
+			"footer": "/* config footer */"
+		}`,
+	})
+
+	run := runMinifyJS(t, dir, "", "-config", "build.json",
+		"-banner", "/* flag banner */")
+	if run.code != 0 {
+		t.Fatalf("exited with %d:\n%s", run.code, run.stderr)
+	}
+
+	main := readTree(t, filepath.Join(dir, "out"))["main.js"]
+	if !strings.HasPrefix(main, "/* flag banner */") {
+		t.Errorf("the flag doesn't override the config:\n%s", main)
+	}
+	if !strings.Contains(main, "/* config footer */") {
+		t.Errorf("the config is not applied:\n%s", main)
+	}
+}
+
+func TestMapFlag(t *testing.T) {
+	var m mapFlag
+	for _, v := range []string{`VERSION="7.6.0"`, "EMPTY=", "A=b=c"} {
+		if err := m.Set(v); err != nil {
+			t.Errorf("Set(%q): %v", v, err)
+		}
+	}
+	expected := mapFlag{"VERSION": `"7.6.0"`, "EMPTY": "", "A": "b=c"}
+	if fmt.Sprint(m) != fmt.Sprint(expected) {
+		t.Errorf("unexpected map %v, expected %v", m, expected)
+	}
+
+	for _, v := range []string{"VERSION", "=value"} {
+		if err := m.Set(v); err == nil {
+			t.Errorf("Set(%q) accepts an entry that is not key=value", v)
+		}
+	}
+}
+
+func TestBuildErrorExitCode(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": `let x = ;`,
+	})
+
+	run := runMinifyJS(t, dir, "", treeArgs...)
+
+	if run.code != exitBuildFailed {
+		t.Errorf("expected exit code %d, got %d", exitBuildFailed, run.code)
+	}
+	for _, text := range []string{`[ERROR] Unexpected ";"`,
+		"ui/app/main.js:1:8:"} {
+		if strings.Count(run.stderr, text) != 1 {
+			t.Errorf("expected %q once on stderr:\n%s", text, run.stderr)
+		}
+	}
+}
+
+func TestBadUsageExitCode(t *testing.T) {
+	dir := newTestTree(t, nil)
+
+	for _, args := range [][]string{
+		{"-no-such-flag"},
+		{"-in-dir", "."},
+		buildArgs("-format", "amd"),
+	} {
+		run := runMinifyJS(t, dir, "", args...)
+
+		if run.code != exitBadUsage {
+			t.Errorf("%v: expected exit code %d, got %d:\n%s",
+				args, exitBadUsage, run.code, run.stderr)
+		}
+	}
+}
+
+func TestStrictImportMapDefault(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"ui/app/node_modules/unmapped/index.js": `export default 1;`,
+		"ui/app/main.js": `import unmapped from "unmapped";
+			console.info(unmapped);`,
+	})
+
+	run := runMinifyJS(t, dir, "", treeArgs...)
+
+	if run.code != exitBuildFailed || !strings.Contains(run.stderr,
+		`bare specifier "unmapped" imported by`) {
+		t.Errorf("expected the unmapped import to fail the build, got "+
+			"exit code %d:\n%s", run.code, run.stderr)
+	}
+
+	run = runMinifyJS(t, dir, "", buildArgs("-strict-importmap=false")...)
+
+	if run.code != 0 {
+		t.Errorf("expected -strict-importmap=false to build, got exit "+
+			"code %d:\n%s", run.code, run.stderr)
+	}
+}
+
+func TestPostBuildErrorPrinted(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info(1);`,
+	})
+
+	run := runMinifyJS(t, dir, "", buildArgs("-archive",
+		"missing/out.tgz")...)
+
+	if run.code != exitBuildFailed {
+		t.Errorf("expected exit code %d, got %d", exitBuildFailed, run.code)
+	}
+	if strings.Count(run.stderr, "cannot write archive") != 1 {
+		t.Errorf("expected the archive error once on stderr:\n%s",
+			run.stderr)
+	}
+}
+
+func TestStdin(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"ui/app/helper.js": `export const helper = "from the helper";`,
+	})
+
+	run := runMinifyJS(t, dir, `import { helper } from "./ui/app/helper.js";
+		const answer: number = 42;
+		console.info(helper, answer);`,
+		"-in-dir", ".", "-importmap-path", "ui/importmap.json", "-stdin",
+		"-stdin-loader", "ts")
+
+	if run.code != 0 {
+		t.Fatalf("exited with %d:\n%s", run.code, run.stderr)
+	}
+	expected := `var helper="from the helper";var answer=42;` +
+		"console.info(helper,answer);\n"
+	if run.stdout != expected {
+		t.Errorf("unexpected stdout %q, expected %q", run.stdout, expected)
+	}
+}
+
+func TestLoaderFlagMerges(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": `import tpl from "./tpl.html";
+			import icon from "./icon.svg";
+			console.info(tpl, icon);`,
+		"ui/app/tpl.html": `<p>template</p>`,
+		"ui/app/icon.svg": `<svg>icon</svg>`,
+	})
+
+	run := runMinifyJS(t, dir, "", buildArgs("-loader", ".svg=text")...)
+
+	if run.code != 0 {
+		t.Fatalf("exited with %d:\n%s", run.code, run.stderr)
+	}
+	main := readTree(t, filepath.Join(dir, "out"))["main.js"]
+	for _, text := range []string{"<p>template</p>", "<svg>icon</svg>"} {
+		if !strings.Contains(main, text) {
+			t.Errorf("%q is missing from main.js:\n%s", text, main)
+		}
+	}
+}
+
+func TestDirChecks(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info(1);`,
+	})
+
+	for _, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{
+			[]string{"-in-dir", "missing"},
+			"Error: cannot access source dir " +
+				filepath.Join(dir, "missing") + ": ",
+		},
+		{
+			[]string{"-in-dir", "ui/app/main.js"},
+			"Error: source dir " + filepath.Join(dir, "ui", "app", "main.js") +
+				" is not a directory",
+		},
+		{
+			[]string{"-out-dir", "missing/out"},
+			"Error: parent dir " + filepath.Join(dir, "missing") +
+				" of output dir " + filepath.Join(dir, "missing", "out") +
+				" must be an existing directory",
+		},
+	} {
+		run := runMinifyJS(t, dir, "", buildArgs(test.args...)...)
+
+		if run.code != exitBadUsage {
+			t.Errorf("%v: expected exit code %d, got %d", test.args,
+				exitBadUsage, run.code)
+		}
+		if !strings.Contains(run.stderr, "\n"+test.expected) &&
+			!strings.HasPrefix(run.stderr, test.expected) {
+			t.Errorf("%v: expected %q on stderr:\n%s", test.args,
+				test.expected, run.stderr)
+		}
+	}
+}
+
+func TestMissingOutDir(t *testing.T) {
+	dir := newTestTree(t, nil)
+
+	run := runMinifyJS(t, dir, "", "-in-dir", ".", "-importmap-path",
+		"ui/importmap.json")
+
+	const expected = "Error: path to js output dir must be specified\n"
+	if run.code != exitBadUsage || !strings.HasPrefix(run.stderr, expected) {
+		t.Errorf("expected exit code %d and %q, got %d:\n%s", exitBadUsage,
+			expected, run.code, run.stderr)
+	}
+}
+
+func TestJSONLogFormat(t *testing.T) {
+	type message struct {
+		File   string `json:"file"`
+		Line   int    `json:"line"`
+		Column int    `json:"column"`
+		Text   string `json:"text"`
+		Level  string `json:"level"`
+	}
+	for _, test := range []struct {
+		main     string
+		code     int
+		expected message
+	}{
+		{
+			"let x = 1;\nlet y = ;", exitBuildFailed,
+			message{"ui/app/main.js", 2, 8, `Unexpected ";"`, "error"},
+		},
+		{
+			"if (x == -0) {}", 0,
+			message{"ui/app/main.js", 1, 9, `Comparison with -0 using the ` +
+				`"==" operator will also match 0`, "warning"},
+		},
+	} {
+		dir := newTestTree(t, map[string]string{"ui/app/main.js": test.main})
+
+		run := runMinifyJS(t, dir, "", buildArgs("-log-format", "json")...)
+
+		if run.code != test.code {
+			t.Errorf("%s: expected exit code %d, got %d", test.expected.Level,
+				test.code, run.code)
+		}
+		var messages []message
+		if err := json.Unmarshal([]byte(run.stdout), &messages); err != nil {
+			t.Errorf("stdout is not a JSON array of messages: %v\n%s", err,
+				run.stdout)
+		} else if len(messages) != 1 || messages[0] != test.expected {
+			t.Errorf("unexpected messages %+v, expected %+v", messages,
+				test.expected)
+		}
+		if strings.Contains(run.stderr, test.expected.Text) {
+			t.Errorf("esbuild logs the %s on stderr too:\n%s",
+				test.expected.Level, run.stderr)
+		}
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("main");`,
+	})
+	outDir := filepath.Join(dir, "out")
+	if err := os.Mkdir(outDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	dryRun := runMinifyJS(t, dir, "", buildArgs("-dry-run")...)
+	if dryRun.code != 0 {
+		t.Fatalf("exited with %d:\n%s", dryRun.code, dryRun.stderr)
+	}
+
+	if files := readTree(t, outDir); len(files) != 0 {
+		t.Errorf("a dry run writes to the out dir: %v", files)
+	}
+	run := runMinifyJS(t, dir, "", buildArgs("-out-dir", "written")...)
+	if run.code != 0 {
+		t.Fatalf("exited with %d:\n%s", run.code, run.stderr)
+	}
+	var expected []string
+	for name, contents := range readTree(t, filepath.Join(dir, "written")) {
+		expected = append(expected, fmt.Sprintf("%s\t%d",
+			filepath.Join(outDir, filepath.FromSlash(name)), len(contents)))
+	}
+	listed := strings.Split(strings.TrimSuffix(dryRun.stdout, "\n"), "\n")
+	slices.Sort(expected)
+	slices.Sort(listed)
+	if !slices.Equal(listed, expected) {
+		t.Errorf("unexpected outputs listed %q, expected %q", listed,
+			expected)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	run := runMinifyJS(t, t.TempDir(), "", "-version")
+
+	if run.code != 0 {
+		t.Errorf("exited with %d:\n%s", run.code, run.stderr)
+	}
+	for _, re := range []string{`(?m)^minify_js \S+$`,
+		`(?m)^esbuild v\d+\.\d+\.\d+$`,
+		`(?m)^` + regexp.QuoteMeta(runtime.Version()) + `$`} {
+		if !regexp.MustCompile(re).MatchString(run.stdout) {
+			t.Errorf("%s doesn't match the output:\n%s", re, run.stdout)
+		}
+	}
+}
+
+func TestRelativeDirs(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"tree/ui/importmap.json": `{"imports": {}}`,
+		"tree/ui/app/main.js":    `console.info("main");`,
+	})
+	workDir := filepath.Join(dir, "work")
+	if err := os.Mkdir(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	run := runMinifyJS(t, workDir, "", "-in-dir", "../tree", "-out-dir",
+		"../out", "-importmap-path", "../tree/ui/importmap.json")
+	if run.code != 0 {
+		t.Fatalf("exited with %d:\n%s", run.code, run.stderr)
+	}
+
+	if files := readTree(t, workDir); len(files) != 0 {
+		t.Errorf("outputs are written to the working dir: %v", files)
+	}
+	outputs := readTree(t, filepath.Join(dir, "out"))
+	if !strings.Contains(outputs["main.js"], `"main"`) {
+		t.Errorf("unexpected outputs %v", outputs)
+	}
+	var sourcemap struct {
+		Sources []string `json:"sources"`
+	}
+	err := json.Unmarshal([]byte(outputs["main.js.map"]), &sourcemap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"../tree/ui/app/main.js"}
+	if !slices.Equal(sourcemap.Sources, expected) {
+		t.Errorf("unexpected sources %v, expected %v", sourcemap.Sources,
+			expected)
+	}
+}
+
+func TestColor(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": `let x = ;`,
+	})
+
+	for _, test := range []struct {
+		color  string
+		escape bool
+	}{
+		{"never", false},
+		{"always", true},
+	} {
+		run := runMinifyJS(t, dir, "", buildArgs("-color", test.color)...)
+
+		escape := strings.Contains(run.stderr, "\x1b[")
+		if escape != test.escape {
+			t.Errorf("-color %s: escape sequences %v, expected %v:\n%q",
+				test.color, escape, test.escape, run.stderr)
+		}
+	}
+}
+
+func TestLogLimit(t *testing.T) {
+	var imports []string
+	for i := 0; i < 8; i++ {
+		imports = append(imports, fmt.Sprintf(`import "./missing%d.js";`, i))
+	}
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": strings.Join(imports, "\n"),
+	})
+
+	for _, test := range []struct {
+		limit  string
+		errors int
+	}{
+		{"3", 3},
+		{"0", 8},
+	} {
+		run := runMinifyJS(t, dir, "", buildArgs("-log-limit",
+			test.limit)...)
+
+		printed := strings.Count(run.stderr, "[ERROR]")
+		if printed != test.errors {
+			t.Errorf("-log-limit %s: %d errors printed, expected %d:\n%s",
+				test.limit, printed, test.errors, run.stderr)
+		}
+	}
+}
+
+func TestWarningsAsErrors(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": `if (x == -0) {}`,
+	})
+
+	for _, test := range []struct {
+		args []string
+		code int
+	}{
+		{nil, 0},
+		{[]string{"-warnings-as-errors"}, exitBuildFailed},
+	} {
+		run := runMinifyJS(t, dir, "", buildArgs(test.args...)...)
+
+		if run.code != test.code {
+			t.Errorf("%v: expected exit code %d, got %d:\n%s", test.args,
+				test.code, run.code, run.stderr)
+		}
+		if !strings.Contains(run.stderr, "Comparison with -0") {
+			t.Errorf("%v: the warning is not printed:\n%s", test.args,
+				run.stderr)
+		}
+	}
+}
+
+func TestQuiet(t *testing.T) {
+	// The empty import map of the test tree is warned about by the tool
+	// itself, the comparison by esbuild.
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": `if (x == -0) {}`,
+	})
+
+	run := runMinifyJS(t, dir, "", buildArgs()...)
+	if !strings.Contains(run.stderr, "has no imports") ||
+		!strings.Contains(run.stderr, "Comparison with -0") {
+		t.Fatalf("expected both warnings without -quiet:\n%s", run.stderr)
+	}
+
+	run = runMinifyJS(t, dir, "", buildArgs("-quiet")...)
+	if run.code != 0 {
+		t.Fatalf("expected exit code 0, got %d:\n%s", run.code, run.stderr)
+	}
+	if run.stderr != "" {
+		t.Errorf("expected no output with -quiet, got:\n%s", run.stderr)
+	}
+}
+
+// fingerprint returns the -fingerprint of a new test tree with main.js,
+// checking that nothing is written.
+func fingerprint(t *testing.T, main string) string {
+	t.Helper()
+	dir := newTestTree(t, map[string]string{"ui/app/main.js": main})
+
+	run := runMinifyJS(t, dir, "", buildArgs("-fingerprint")...)
+
+	if run.code != 0 {
+		t.Fatalf("exited with %d:\n%s", run.code, run.stderr)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{64}\n$`).MatchString(run.stdout) {
+		t.Fatalf("unexpected fingerprint %q", run.stdout)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out")); err == nil {
+		t.Errorf("-fingerprint writes the outputs")
+	}
+	return run.stdout
+}
+
+func TestFingerprint(t *testing.T) {
+	first := fingerprint(t, `console.info("main");`)
+
+	if second := fingerprint(t, `console.info("main");`); second != first {
+		t.Errorf("identical builds have the fingerprints %s and %s",
+			first, second)
+	}
+	if changed := fingerprint(t, `console.info("maim");`); changed == first {
+		t.Errorf("a one byte change keeps the fingerprint %s", first)
+	}
+}
+
+func TestEnvNodePath(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"flag_modules/from-flag.js": `export const a = "from the flag";`,
+		"env_modules/from-env.js":   `export const b = "from NODE_PATH";`,
+		"ui/app/main.js": `import { a } from "from-flag.js";
+			import { b } from "from-env.js";
+			console.info(a, b);`,
+	})
+	t.Setenv("NODE_PATH", filepath.Join(dir, "env_modules"))
+
+	run := runMinifyJS(t, dir, "", buildArgs("-resolve-mode", "nodepaths",
+		"-node-path", "flag_modules")...)
+
+	if run.code != 0 {
+		t.Fatalf("exited with %d:\n%s", run.code, run.stderr)
+	}
+	main := readTree(t, filepath.Join(dir, "out"))["main.js"]
+	for _, text := range []string{"from the flag", "from NODE_PATH"} {
+		if !strings.Contains(main, text) {
+			t.Errorf("%q isn't resolved:\n%s", text, main)
+		}
+	}
+}
+
+func TestBuildMessagesFormatted(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": "if (x == -0) {}\nimport \"./missing.js\";\n",
+	})
+
+	run := runMinifyJS(t, dir, "", buildArgs("-color", "never")...)
+
+	if run.code != exitBuildFailed {
+		t.Errorf("expected exit code %d, got %d", exitBuildFailed, run.code)
+	}
+	for _, text := range []string{
+		"✘ [ERROR] Could not resolve \"./missing.js\"\n\n" +
+			"    ui/app/main.js:2:7:\n" +
+			"      2 │ import \"./missing.js\";\n",
+		"▲ [WARNING] Comparison with -0",
+		"    ui/app/main.js:1:9:\n      1 │ if (x == -0) {}\n",
+	} {
+		if strings.Count(run.stderr, text) != 1 {
+			t.Errorf("expected %q once on stderr:\n%s", text, run.stderr)
+		}
+	}
+	if strings.Index(run.stderr, "[ERROR]") >
+		strings.Index(run.stderr, "[WARNING]") {
+		t.Errorf("the error isn't printed first:\n%s", run.stderr)
+	}
+}
+
+func TestOutputList(t *testing.T) {
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": `console.info("main");`,
+	})
+
+	run := runMinifyJS(t, dir, "", buildArgs("-sourcemap", "none")...)
+
+	if run.code != 0 {
+		t.Fatalf("exited with %d:\n%s", run.code, run.stderr)
+	}
+	main := readTree(t, filepath.Join(dir, "out"))["main.js"]
+	listed := fmt.Sprintf("  %s  %d B\n", filepath.Join("out", "main.js"),
+		len(main))
+	if !strings.HasSuffix(run.stderr, listed) {
+		t.Errorf("expected the output %q to be listed:\n%s", listed,
+			run.stderr)
+	}
+}
@@ -0,0 +1,25 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/couchbase/ns_server/deps/gocode/jsbuild"
+)
+
+// exitOnPanic turns a panic of the calling goroutine into an error message,
+// instead of a goroutine dump. It has to be deferred.
+func exitOnPanic() {
+	if r := recover(); r != nil {
+		log.Printf("Error: build crashed: %v (%s)\n", r, jsbuild.PanicHint)
+		os.Exit(exitBuildFailed)
+	}
+}
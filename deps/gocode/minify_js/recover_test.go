@@ -0,0 +1,49 @@
+// @author Couchbase <info@couchbase.com>
+// @copyright 2026-Present Couchbase, Inc.
+//
+// Use of this software is governed by the Business Source License included in
+// the file licenses/BSL-Couchbase.txt.  As of the Change Date specified in that
+// file, in accordance with the Business Source License, use of this software
+// will be governed by the Apache License, Version 2.0, included in the file
+// licenses/APL2.txt.
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/couchbase/ns_server/deps/gocode/jsbuild"
+)
+
+func TestImportMapPluginPanic(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skipf("can't build minify_js: %v", err)
+	}
+	// with the tag, the import map plugin panics on jsbuild.PanicSpecifier
+	exe := filepath.Join(t.TempDir(), "minify_js")
+	build := exec.Command(goTool, "build", "-tags", "jsbuild_panic_hook",
+		"-o", exe, ".")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("can't build minify_js: %v\n%s", err, out)
+	}
+	dir := newTestTree(t, map[string]string{
+		"ui/app/main.js": `import "jsbuild-panic";`,
+	})
+
+	run := runExecutable(t, exe, dir, "", buildArgs("-color", "never")...)
+
+	if run.code != exitBuildFailed {
+		t.Errorf("expected exit code %d, got %d", exitBuildFailed, run.code)
+	}
+	expected := "✘ [ERROR] panic: the jsbuild_panic_hook tag (" +
+		jsbuild.PanicHint + ") [plugin ImportMap]"
+	if !strings.Contains(run.stderr, expected) {
+		t.Errorf("expected %q on stderr:\n%s", expected, run.stderr)
+	}
+	if strings.Contains(run.stderr, "goroutine ") {
+		t.Errorf("a stack trace is printed:\n%s", run.stderr)
+	}
+}